@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/bedrock"
+)
+
+type DiscoverBedrockCommand struct {
+	timeout time.Duration
+}
+
+func (cmd *DiscoverBedrockCommand) Flags(fs *flag.FlagSet) {
+	fs.DurationVar(&cmd.timeout, "timeout", 2*time.Second, "")
+}
+
+func (DiscoverBedrockCommand) MinNumberOfArguments() int {
+	return 0
+}
+
+func (DiscoverBedrockCommand) MaxNumberOfArguments() int {
+	return 0
+}
+
+func (DiscoverBedrockCommand) Usage() string {
+	return "[--timeout <duration>]"
+}
+
+func (cmd DiscoverBedrockCommand) Execute(_ []string, jsonFormat bool) bool {
+	servers, err := bedrock.Discover(cmd.timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
+		return false
+	}
+
+	if jsonFormat {
+		return cmd.jsonOutput(servers)
+	}
+
+	return cmd.basicOutput(servers)
+}
+
+func (DiscoverBedrockCommand) basicOutput(servers []bedrock.DiscoveredServer) bool {
+	if len(servers) == 0 {
+		fmt.Println("No server found.")
+		return true
+	}
+
+	for i, server := range servers {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Address : %s\n", server.Address)
+		fmt.Printf("Server GUID : %d\n", server.ServerGUID)
+		fmt.Printf("MOTD : %s\n", formatComponent(server.Pong.MOTDComponent()))
+		fmt.Printf("Level Name : %s\n", server.Pong.LevelName)
+		fmt.Printf("Minecraft Version : %s\n", server.Pong.MinecraftVersion)
+		fmt.Printf("Players : %d/%d\n", server.Pong.OnlinePlayers, server.Pong.MaxPlayers)
+		fmt.Printf("Game Mode : %s\n", server.Pong.GameMode)
+		fmt.Printf("IPv4 Port : %d\n", server.Pong.IPv4Port)
+		fmt.Printf("IPv6 Port : %d\n", server.Pong.IPv6Port)
+		fmt.Printf("Latency : %d ms\n", server.Latency)
+	}
+
+	return true
+}
+
+func (DiscoverBedrockCommand) jsonOutput(servers []bedrock.DiscoveredServer) bool {
+	encoder := json.NewEncoder(os.Stdout)
+	err := encoder.Encode(servers)
+
+	if err != nil {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+
+	"github.com/xrjr/mcutils/pkg/chat"
+)
+
+// isTerminal returns true if f is a terminal (character device).
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// formatComponent renders a text component with colors if the standard output is a terminal, or as plain text otherwise.
+func formatComponent(c chat.Component) string {
+	if isTerminal(os.Stdout) {
+		return c.ANSI()
+	}
+	return c.PlainText()
+}
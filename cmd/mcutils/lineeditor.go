@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	maxHistoryLength int = 1000
+)
+
+var errInterrupted error = errors.New("interrupted")
+
+// lineEditor reads lines from a terminal, with basic line editing (cursor moves, history, tab completion).
+// If the input isn't a terminal, or if its mode can't be changed, lines are read as is.
+type lineEditor struct {
+	in     *os.File
+	reader *bufio.Reader
+	out    io.Writer
+
+	history     []string
+	historyFile string
+
+	// complete returns the possible completions of the word ending the line.
+	complete func(line string) []string
+}
+
+// newLineEditor returns a well-formed *lineEditor reading from in, and echoing to out.
+func newLineEditor(in *os.File, out io.Writer) *lineEditor {
+	return &lineEditor{
+		in:     in,
+		reader: bufio.NewReader(in),
+		out:    out,
+	}
+}
+
+// loadHistory loads the history from name, and appends all the following lines to it.
+// A missing file is not an error, as it is created by the first line.
+func (le *lineEditor) loadHistory(name string) error {
+	le.historyFile = name
+
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			le.history = append(le.history, line)
+		}
+	}
+	if len(le.history) > maxHistoryLength {
+		le.history = le.history[len(le.history)-maxHistoryLength:]
+	}
+
+	return nil
+}
+
+// addHistory adds line to the history, and to the history file.
+func (le *lineEditor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" || (len(le.history) > 0 && le.history[len(le.history)-1] == line) {
+		return
+	}
+
+	le.history = append(le.history, line)
+	if len(le.history) > maxHistoryLength {
+		le.history = le.history[1:]
+	}
+
+	if le.historyFile == "" {
+		return
+	}
+
+	f, err := os.OpenFile(le.historyFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, line)
+}
+
+// readLine displays prompt, and reads a line. It returns io.EOF when the input is closed (or Ctrl-D is typed on an empty line),
+// and errInterrupted when Ctrl-C is typed.
+func (le *lineEditor) readLine(prompt string) (string, error) {
+	if !isTerminal(le.in) {
+		return le.readRawLine(prompt)
+	}
+
+	restore, err := makeRaw(int(le.in.Fd()))
+	if err != nil {
+		return le.readRawLine(prompt)
+	}
+	defer restore()
+
+	line, err := le.edit(prompt)
+	if err == nil {
+		le.addHistory(line)
+	}
+
+	return line, err
+}
+
+// readRawLine reads a line without editing.
+func (le *lineEditor) readRawLine(prompt string) (string, error) {
+	if isTerminal(le.in) {
+		fmt.Fprint(le.out, prompt)
+	}
+
+	line, err := le.reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// lineState is the state of the line being edited.
+type lineState struct {
+	prompt string
+	buf    []rune
+	pos    int
+}
+
+// edit reads keys until the line is validated. The terminal must be in raw mode.
+func (le *lineEditor) edit(prompt string) (string, error) {
+	state := &lineState{prompt: prompt}
+	historyIndex := len(le.history)
+	current := ""
+
+	le.refresh(state)
+
+	for {
+		r, _, err := le.reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(le.out, "\n")
+			return string(state.buf), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(le.out, "^C\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(state.buf) == 0 {
+				fmt.Fprint(le.out, "\n")
+				return "", io.EOF
+			}
+			state.deleteAt(state.pos)
+		case 127, 8: // Backspace
+			if state.pos > 0 {
+				state.pos--
+				state.deleteAt(state.pos)
+			}
+		case 1: // Ctrl-A
+			state.pos = 0
+		case 5: // Ctrl-E
+			state.pos = len(state.buf)
+		case 21: // Ctrl-U
+			state.buf = state.buf[state.pos:]
+			state.pos = 0
+		case 11: // Ctrl-K
+			state.buf = state.buf[:state.pos]
+		case '\t':
+			le.completeLine(state)
+		case 27: // Escape sequence
+			switch le.readEscapeSequence() {
+			case "[A", "OA": // Up
+				if historyIndex > 0 {
+					if historyIndex == len(le.history) {
+						current = string(state.buf)
+					}
+					historyIndex--
+					state.set(le.history[historyIndex])
+				}
+			case "[B", "OB": // Down
+				if historyIndex < len(le.history) {
+					historyIndex++
+					if historyIndex == len(le.history) {
+						state.set(current)
+					} else {
+						state.set(le.history[historyIndex])
+					}
+				}
+			case "[C", "OC": // Right
+				if state.pos < len(state.buf) {
+					state.pos++
+				}
+			case "[D", "OD": // Left
+				if state.pos > 0 {
+					state.pos--
+				}
+			case "[H", "OH", "[1~", "[7~": // Home
+				state.pos = 0
+			case "[F", "OF", "[4~", "[8~": // End
+				state.pos = len(state.buf)
+			case "[3~": // Delete
+				state.deleteAt(state.pos)
+			}
+		default:
+			if r >= ' ' {
+				state.insert(r)
+			}
+		}
+
+		le.refresh(state)
+	}
+}
+
+// readEscapeSequence reads the rest of an escape sequence (after the escape character), e.g. "[A" for the up arrow.
+func (le *lineEditor) readEscapeSequence() string {
+	var sb strings.Builder
+
+	r, _, err := le.reader.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return ""
+	}
+	sb.WriteRune(r)
+
+	for {
+		r, _, err = le.reader.ReadRune()
+		if err != nil {
+			return ""
+		}
+		sb.WriteRune(r)
+
+		// Final bytes of control sequences are in the range 0x40-0x7E.
+		if r >= 0x40 && r <= 0x7E {
+			return sb.String()
+		}
+	}
+}
+
+// completeLine completes the word ending the line : with the only possible completion, or with the longest common prefix of all completions.
+// If the line can't be completed further, possible completions are listed.
+func (le *lineEditor) completeLine(state *lineState) {
+	if le.complete == nil || state.pos != len(state.buf) {
+		return
+	}
+
+	line := string(state.buf)
+	word := line[strings.LastIndex(line, " ")+1:]
+
+	completions := le.complete(line)
+	if len(completions) == 0 {
+		return
+	}
+
+	if len(completions) == 1 {
+		state.set(line[:len(line)-len(word)] + completions[0] + " ")
+		return
+	}
+
+	prefix := commonPrefix(completions)
+	if len(prefix) > len(word) {
+		state.set(line[:len(line)-len(word)] + prefix)
+		return
+	}
+
+	fmt.Fprintf(le.out, "\r\n%s\n", strings.Join(completions, "  "))
+}
+
+// refresh redraws the prompt and the line, and places the cursor.
+func (le *lineEditor) refresh(state *lineState) {
+	fmt.Fprintf(le.out, "\r%s%s\x1b[K", state.prompt, string(state.buf))
+
+	if back := len(state.buf) - state.pos; back > 0 {
+		fmt.Fprintf(le.out, "\x1b[%dD", back)
+	}
+}
+
+// set replaces the line, and places the cursor at its end.
+func (state *lineState) set(line string) {
+	state.buf = []rune(line)
+	state.pos = len(state.buf)
+}
+
+// insert inserts r at the cursor.
+func (state *lineState) insert(r rune) {
+	state.buf = append(state.buf, 0)
+	copy(state.buf[state.pos+1:], state.buf[state.pos:])
+	state.buf[state.pos] = r
+	state.pos++
+}
+
+// deleteAt deletes the rune at position i, if any.
+func (state *lineState) deleteAt(i int) {
+	if i < len(state.buf) {
+		state.buf = append(state.buf[:i], state.buf[i+1:]...)
+	}
+}
+
+// commonPrefix returns the longest common prefix of words.
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
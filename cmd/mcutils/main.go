@@ -4,6 +4,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+
+	"github.com/xrjr/mcutils/pkg/networking"
 )
 
 type Command interface {
@@ -13,15 +15,23 @@ type Command interface {
 	Usage() string
 }
 
+// FlagsCommand is implemented by commands accepting their own flags, placed between the command name and its parameters.
+type FlagsCommand interface {
+	Flags(fs *flag.FlagSet)
+}
+
 var (
 	commands map[string]Command = map[string]Command{
-		"ping":              PingCommand{},
+		"ping":              &PingCommand{},
 		"query-basic":       QueryBasicCommand{},
 		"query-full":        QueryFullCommand{},
-		"rcon":              RconCommand{},
+		"rcon":              &RconCommand{},
+		"rcon-shell":        &RconShellCommand{},
 		"ping-legacy":       PingLegacyCommand{},
 		"ping-legacy-1.6.4": PingLegacy1_6_4Command{},
-		"ping-bedrock":      PingBedrockCommand{},
+		"ping-bedrock":      &PingBedrockCommand{},
+		"discover-bedrock":  &DiscoverBedrockCommand{},
+		"probe":             &ProbeCommand{},
 		"version":           VersionCommand{},
 		"help":              HelpCommand{},
 	}
@@ -29,10 +39,11 @@ var (
 
 func main() {
 	var jsonFormat *bool = flag.Bool("json", false, "")
+	var proxy *string = flag.String("proxy", "", "")
 	flag.Parse()
 
 	if len(flag.Args()) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage : %s [--json] <command> <params...>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage : %s [--json] [--proxy <url>] <command> <params...>\n", os.Args[0])
 		fmt.Printf("Run '%s help' to see existing commands.\n", os.Args[0])
 
 		os.Exit(1)
@@ -48,19 +59,47 @@ func main() {
 		return
 	}
 
-	commandArgsNumber := len(flag.Args()) - 1
+	if *proxy != "" {
+		dialer, err := networking.NewProxyDialer(*proxy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid proxy : %s.\n", err.Error())
+
+			os.Exit(1)
+			return
+		}
+
+		networking.DefaultDialer = dialer
+	}
+
+	commandArgs := flag.Args()[1:]
+
+	if flagsCommand, ok := command.(FlagsCommand); ok {
+		fs := flag.NewFlagSet(flag.Arg(0), flag.ContinueOnError)
+		fs.Usage = func() {}
+		flagsCommand.Flags(fs)
+
+		err := fs.Parse(commandArgs)
+		if err != nil {
+			showUsageAndExit(command)
+			return
+		}
+
+		commandArgs = fs.Args()
+	}
+
+	commandArgsNumber := len(commandArgs)
 	if commandArgsNumber < command.MinNumberOfArguments() || commandArgsNumber > command.MaxNumberOfArguments() {
 		fmt.Fprintf(os.Stderr, "Invalid number of arguments (current=%d, min=%d, max=%d).\n", commandArgsNumber, command.MinNumberOfArguments(), command.MaxNumberOfArguments())
 		showUsageAndExit(command)
 		return
 	}
 
-	if !command.Execute(flag.Args()[1:], *jsonFormat) {
+	if !command.Execute(commandArgs, *jsonFormat) {
 		showUsageAndExit(command)
 	}
 }
 
 func showUsageAndExit(command Command) {
-	fmt.Fprintf(os.Stderr, "Usage : %s [--json] %s %s\n", os.Args[0], flag.Arg(0), command.Usage())
+	fmt.Fprintf(os.Stderr, "Usage : %s [--json] [--proxy <url>] %s %s\n", os.Args[0], flag.Arg(0), command.Usage())
 	os.Exit(1)
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
@@ -9,7 +10,13 @@ import (
 	"github.com/xrjr/mcutils/pkg/bedrock"
 )
 
-type PingBedrockCommand struct{}
+type PingBedrockCommand struct {
+	handshake bool
+}
+
+func (cmd *PingBedrockCommand) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&cmd.handshake, "handshake", false, "")
+}
 
 func (PingBedrockCommand) MinNumberOfArguments() int {
 	return 2
@@ -20,7 +27,7 @@ func (PingBedrockCommand) MaxNumberOfArguments() int {
 }
 
 func (PingBedrockCommand) Usage() string {
-	return "<hostname> <port>"
+	return "[--handshake] <hostname> <port>"
 }
 
 func (cmd PingBedrockCommand) Execute(params []string, jsonFormat bool) bool {
@@ -36,16 +43,23 @@ func (cmd PingBedrockCommand) Execute(params []string, jsonFormat bool) bool {
 		return false
 	}
 
+	var connection *bedrock.ConnectionInfo
+	var connectionErr error
+	if cmd.handshake {
+		info, err := bedrock.OpenConnection(params[0], port)
+		connection, connectionErr = &info, err
+	}
+
 	if jsonFormat {
-		return cmd.jsonOutput(pong, latency)
+		return cmd.jsonOutput(pong, latency, connection, connectionErr)
 	}
 
-	return cmd.jsonOutput(pong, latency)
+	return cmd.basicOutput(pong, latency, connection, connectionErr)
 }
 
-func (PingBedrockCommand) basicOutput(pong bedrock.UnconnectedPong, latency int) bool {
+func (PingBedrockCommand) basicOutput(pong bedrock.UnconnectedPong, latency int, connection *bedrock.ConnectionInfo, connectionErr error) bool {
 	fmt.Printf("Game Name : %s\n", pong.GameName)
-	fmt.Printf("MOTD : %s\n", pong.MOTD)
+	fmt.Printf("MOTD : %s\n", formatComponent(pong.MOTDComponent()))
 	fmt.Printf("Protocol Version : %d\n", pong.ProtocolVersion)
 	fmt.Printf("Minecraft Version : %s\n", pong.MinecraftVersion)
 	fmt.Printf("Online Players : %d\n", pong.OnlinePlayers)
@@ -58,16 +72,35 @@ func (PingBedrockCommand) basicOutput(pong bedrock.UnconnectedPong, latency int)
 	fmt.Printf("IPv6 Port : %d\n", pong.IPv6Port)
 	fmt.Printf("Latency : %d ms\n", latency)
 
+	if connectionErr == bedrock.ErrIncompatibleProtocol {
+		fmt.Printf("Connection : %s (server expects %d)\n", connectionErr.Error(), connection.ProtocolVersion)
+	} else if connectionErr != nil {
+		fmt.Printf("Connection : %s\n", connectionErr.Error())
+	} else if connection != nil {
+		fmt.Printf("Connection : accepted\n")
+		fmt.Printf("Server GUID : %d\n", connection.ServerGUID)
+		fmt.Printf("MTU : %d\n", connection.MTU)
+		fmt.Printf("Security : %t\n", connection.Security)
+		fmt.Printf("RakNet Protocol Version : %d\n", connection.ProtocolVersion)
+	}
+
 	return true
 }
 
-func (PingBedrockCommand) jsonOutput(pong bedrock.UnconnectedPong, latency int) bool {
+func (PingBedrockCommand) jsonOutput(pong bedrock.UnconnectedPong, latency int, connection *bedrock.ConnectionInfo, connectionErr error) bool {
 	res := struct {
 		bedrock.UnconnectedPong
-		Latency int `json:"latency"`
+		Latency         int                     `json:"latency"`
+		Connection      *bedrock.ConnectionInfo `json:"connection,omitempty"`
+		ConnectionError string                  `json:"connectionError,omitempty"`
 	}{
 		UnconnectedPong: pong,
 		Latency:         latency,
+		Connection:      connection,
+	}
+
+	if connectionErr != nil {
+		res.ConnectionError = connectionErr.Error()
 	}
 
 	encoder := json.NewEncoder(os.Stdout)
@@ -46,7 +46,7 @@ func (cmd PingLegacyCommand) Execute(params []string, jsonFormat bool) bool {
 func (PingLegacyCommand) basicOutput(infos ping.LegacyPingInfos, latency int) bool {
 	fmt.Printf("Protocol Version : %d\n", infos.ProtocolVersion)
 	fmt.Printf("Minecraft Version : %s\n", infos.MinecraftVersion)
-	fmt.Printf("MOTD : %s\n", infos.MOTD)
+	fmt.Printf("MOTD : %s\n", formatComponent(infos.MOTDComponent()))
 	fmt.Printf("Online Players : %d\n", infos.OnlinePlayers)
 	fmt.Printf("Max Players : %d\n", infos.MaxPlayers)
 	fmt.Printf("Latency : %d ms\n", latency)
@@ -2,14 +2,27 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
+	"github.com/xrjr/mcutils/pkg/networking"
 	"github.com/xrjr/mcutils/pkg/ping"
+	"github.com/xrjr/mcutils/pkg/protocol"
 )
 
-type PingCommand struct{}
+type PingCommand struct {
+	favicon  string
+	protocol string
+}
+
+func (cmd *PingCommand) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&cmd.favicon, "favicon", "", "")
+	fs.StringVar(&cmd.protocol, "protocol", "", "")
+}
 
 func (PingCommand) MinNumberOfArguments() int {
 	return 2
@@ -20,7 +33,7 @@ func (PingCommand) MaxNumberOfArguments() int {
 }
 
 func (PingCommand) Usage() string {
-	return "<hostname> <port>"
+	return "[--favicon <file>] [--protocol <version>] <hostname> <port>"
 }
 
 func (cmd PingCommand) Execute(params []string, jsonFormat bool) bool {
@@ -30,39 +43,59 @@ func (cmd PingCommand) Execute(params []string, jsonFormat bool) bool {
 		return false
 	}
 
-	properties, latency, err := ping.Ping(params[0], port)
+	protocolVersion, err := parseProtocolVersion(cmd.protocol)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid protocol version.")
+		return false
+	}
+
+	properties, latency, target, err := pingWithProtocolVersion(params[0], port, protocolVersion)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
 		return false
 	}
 
+	if cmd.favicon != "" {
+		err = ping.Favicon(properties.Infos().Favicon).WriteFile(cmd.favicon)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
+			return false
+		}
+	}
+
 	if jsonFormat {
-		return cmd.jsonOutput(properties, latency)
+		return cmd.jsonOutput(properties, latency, target)
 	}
 
-	return cmd.basicOutput(properties, latency)
+	return cmd.basicOutput(properties, latency, target)
 }
 
-func (PingCommand) basicOutput(properties ping.JSON, latency int) bool {
+func (PingCommand) basicOutput(properties ping.JSON, latency int, target networking.Target) bool {
 	jsonProperties, err := json.MarshalIndent(properties, "", "\t")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
 		return false
 	}
 
+	infos := properties.Infos()
+	fmt.Printf("Version : %s (protocol %s)\n", infos.Version.Name, formatJavaProtocolVersion(infos.Version.Protocol))
+	fmt.Println("Description :", formatComponent(infos.DescriptionComponent))
 	fmt.Println("Properties :", string(jsonProperties))
 	fmt.Printf("Latency : %d ms\n", latency)
+	fmt.Println("Target :", formatTarget(target))
 
 	return true
 }
 
-func (PingCommand) jsonOutput(properties ping.JSON, latency int) bool {
+func (PingCommand) jsonOutput(properties ping.JSON, latency int, target networking.Target) bool {
 	res := struct {
-		Properties ping.JSON `json:"properties"`
-		Latency    int       `json:"latency"`
+		Properties ping.JSON         `json:"properties"`
+		Latency    int               `json:"latency"`
+		Target     networking.Target `json:"target"`
 	}{
 		Properties: properties,
 		Latency:    latency,
+		Target:     target,
 	}
 
 	encoder := json.NewEncoder(os.Stdout)
@@ -74,3 +107,73 @@ func (PingCommand) jsonOutput(properties ping.JSON, latency int) bool {
 
 	return true
 }
+
+// parseProtocolVersion parses a protocol version given either as a number or as a Java Edition release name.
+// An empty string gives ping.UnknownProtocolVersion.
+func parseProtocolVersion(s string) (int32, error) {
+	if s == "" {
+		return ping.UnknownProtocolVersion, nil
+	}
+
+	if protocolVersion, ok := protocol.JavaProtocol(s); ok {
+		return int32(protocolVersion), nil
+	}
+
+	protocolVersion, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(protocolVersion), nil
+}
+
+// pingWithProtocolVersion is the same as ping.Ping, but sends the given protocol version in the handshake, and also returns the target which answered.
+func pingWithProtocolVersion(hostname string, port int, protocolVersion int32) (ping.JSON, int, networking.Target, error) {
+	client := ping.NewClient(hostname, port)
+	client.ProtocolVersion = protocolVersion
+
+	err := client.Connect()
+	if err != nil {
+		return nil, -1, networking.Target{}, err
+	}
+	defer client.Disconnect()
+
+	target, err := client.Target()
+	if err != nil {
+		return nil, -1, networking.Target{}, err
+	}
+
+	handshake, err := client.Handshake()
+	if err != nil {
+		return nil, -1, networking.Target{}, err
+	}
+
+	latency, err := client.Ping()
+	if err != nil && !errors.Is(err, ping.ErrInvalidPacketType) {
+		return nil, -1, networking.Target{}, err
+	}
+
+	return handshake.Properties, latency, target, nil
+}
+
+// formatTarget formats the target which answered, telling whether it comes from a SRV record.
+func formatTarget(target networking.Target) string {
+	if target.SRV {
+		return target.String() + " (SRV record)"
+	}
+	return target.String()
+}
+
+// formatJavaProtocolVersion formats a netty protocol version, along with the releases using it.
+func formatJavaProtocolVersion(protocolVersion int) string {
+	if protocol.IsJavaSnapshot(protocolVersion) {
+		return fmt.Sprintf("%d, snapshot", protocolVersion)
+	}
+
+	releases := protocol.JavaReleases(protocolVersion)
+	if len(releases) == 0 {
+		return strconv.Itoa(protocolVersion)
+	}
+
+	return fmt.Sprintf("%d, %s", protocolVersion, strings.Join(releases, "/"))
+}
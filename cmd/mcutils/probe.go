@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/xrjr/mcutils/pkg/probe"
+	"github.com/xrjr/mcutils/pkg/status"
+)
+
+type ProbeCommand struct {
+	parallel bool
+}
+
+func (cmd *ProbeCommand) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&cmd.parallel, "parallel", false, "")
+}
+
+func (ProbeCommand) MinNumberOfArguments() int {
+	return 1
+}
+
+func (ProbeCommand) MaxNumberOfArguments() int {
+	return 2
+}
+
+func (ProbeCommand) Usage() string {
+	return "[--parallel] <hostname> [port]"
+}
+
+func (cmd ProbeCommand) Execute(params []string, jsonFormat bool) bool {
+	port := 0
+	if len(params) > 1 {
+		var err error
+		port, err = strconv.Atoi(params[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid port.")
+			return false
+		}
+	}
+
+	client := probe.NewClient(params[0], port)
+	client.Parallel = cmd.parallel
+
+	res, err := client.Probe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
+		return false
+	}
+
+	if jsonFormat {
+		return cmd.jsonOutput(res)
+	}
+
+	return cmd.basicOutput(res)
+}
+
+func (ProbeCommand) basicOutput(res probe.Result) bool {
+	fmt.Printf("Protocol : %s\n", res.Protocol)
+
+	ss := res.ServerStatus()
+	fmt.Printf("Edition : %s\n", ss.Edition)
+	fmt.Printf("Version : %s\n", ss.Version)
+	fmt.Printf("Protocol Version : %d\n", ss.Protocol)
+	fmt.Printf("MOTD : %s\n", formatComponent(ss.MOTD))
+	fmt.Printf("Online Players : %d\n", ss.OnlinePlayers)
+	fmt.Printf("Max Players : %d\n", ss.MaxPlayers)
+
+	fmt.Printf("Latency : %d ms\n", res.Latency)
+
+	return true
+}
+
+func (ProbeCommand) jsonOutput(res probe.Result) bool {
+	output := struct {
+		probe.Result
+		ServerStatus status.ServerStatus `json:"serverStatus"`
+	}{
+		Result:       res,
+		ServerStatus: res.ServerStatus(),
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	err := encoder.Encode(output)
+
+	if err != nil {
+		return false
+	}
+
+	return true
+}
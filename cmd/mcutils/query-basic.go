@@ -44,7 +44,7 @@ func (cmd QueryBasicCommand) Execute(params []string, jsonFormat bool) bool {
 }
 
 func (QueryBasicCommand) basicOutput(bs query.BasicStat) bool {
-	fmt.Printf("MOTD : %s\n", bs.MOTD)
+	fmt.Printf("MOTD : %s\n", formatComponent(bs.MOTDComponent()))
 	fmt.Printf("Game Type : %s\n", bs.GameType)
 	fmt.Printf("Map : %s\n", bs.Map)
 	fmt.Printf("Num Players : %d\n", bs.NumPlayers)
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xrjr/mcutils/pkg/chat"
+	"github.com/xrjr/mcutils/pkg/rcon"
+)
+
+const (
+	rconPasswordEnv     string = "MCUTILS_RCON_PASSWORD"
+	rconHistoryFileName string = ".mcutils_rcon_history"
+)
+
+// vanillaCommands are the commands of vanilla servers, and the first argument of some of them, used for tab completion.
+var vanillaCommands map[string][]string = map[string][]string{
+	"advancement": {"grant", "revoke"}, "attribute": nil, "ban": nil, "ban-ip": nil, "banlist": {"ips", "players"}, "bossbar": {"add", "get", "list", "remove", "set"},
+	"clear": nil, "clone": nil, "damage": nil, "data": {"get", "merge", "modify", "remove"}, "datapack": {"disable", "enable", "list"}, "debug": {"start", "stop", "function"},
+	"defaultgamemode": {"survival", "creative", "adventure", "spectator"}, "deop": nil, "difficulty": {"peaceful", "easy", "normal", "hard"}, "effect": {"give", "clear"},
+	"enchant": nil, "execute": nil, "experience": {"add", "set", "query"}, "fill": nil, "fillbiome": nil, "forceload": {"add", "remove", "query"}, "function": nil,
+	"gamemode": {"survival", "creative", "adventure", "spectator"}, "gamerule": nil, "give": nil, "help": nil, "item": {"modify", "replace"}, "jfr": {"start", "stop"},
+	"kick": nil, "kill": nil, "list": {"uuids"}, "locate": {"structure", "biome", "poi"}, "loot": nil, "me": nil, "msg": nil, "op": nil, "pardon": nil, "pardon-ip": nil,
+	"particle": nil, "perf": {"start", "stop"}, "place": {"feature", "jigsaw", "structure", "template"}, "playsound": nil, "publish": nil, "random": {"value", "roll", "reset"},
+	"recipe": {"give", "take"}, "reload": nil, "return": nil, "ride": nil, "save-all": {"flush"}, "save-off": nil, "save-on": nil, "say": nil, "schedule": {"function", "clear"},
+	"scoreboard": {"objectives", "players"}, "seed": nil, "setblock": nil, "setidletimeout": nil, "setworldspawn": nil, "spawnpoint": nil, "spectate": nil,
+	"spreadplayers": nil, "stop": nil, "stopsound": nil, "summon": nil, "tag": nil, "team": {"add", "empty", "join", "leave", "list", "modify", "remove"}, "teammsg": nil,
+	"teleport": nil, "tell": nil, "tellraw": nil, "tick": {"query", "rate", "freeze", "unfreeze", "step", "sprint"}, "time": {"add", "query", "set"}, "title": nil, "tm": nil,
+	"tp": nil, "transfer": nil, "trigger": nil, "w": nil, "weather": {"clear", "rain", "thunder"}, "whitelist": {"add", "list", "off", "on", "reload", "remove"},
+	"worldborder": {"add", "center", "damage", "get", "set", "warning"}, "xp": {"add", "set", "query"},
+}
+
+type RconShellCommand struct {
+	passwordFile string
+}
+
+func (cmd *RconShellCommand) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&cmd.passwordFile, "password-file", "", "")
+}
+
+func (RconShellCommand) MinNumberOfArguments() int {
+	return 2
+}
+
+func (RconShellCommand) MaxNumberOfArguments() int {
+	return 2
+}
+
+func (RconShellCommand) Usage() string {
+	return "[--password-file <file>] <hostname> <port>"
+}
+
+func (cmd RconShellCommand) Execute(params []string, jsonFormat bool) bool {
+	port, err := strconv.Atoi(params[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid port.")
+		return false
+	}
+
+	password, err := cmd.password()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
+		return false
+	}
+
+	client := rcon.NewResilientClient(params[0], port, password)
+	client.MaxAttempts = 3
+	defer client.Close()
+
+	err = client.Connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
+		return false
+	}
+
+	editor := newLineEditor(os.Stdin, os.Stdout)
+	editor.complete = completeVanillaCommand
+	if home, err := os.UserHomeDir(); err == nil {
+		editor.loadHistory(filepath.Join(home, rconHistoryFileName))
+	}
+
+	prompt := fmt.Sprintf("%s:%d> ", params[0], port)
+	for {
+		line, err := editor.readLine(prompt)
+		if err == errInterrupted {
+			continue
+		}
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
+			return true
+		}
+
+		command := strings.TrimPrefix(strings.TrimSpace(line), "/")
+		if command == "" {
+			continue
+		}
+		if command == "exit" || command == "quit" {
+			return true
+		}
+
+		response, err := client.Command(command)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
+			continue
+		}
+
+		if jsonFormat {
+			cmd.jsonOutput(command, response)
+		} else {
+			cmd.basicOutput(response)
+		}
+	}
+}
+
+// password returns the rcon password, from the environment, the password file, or a prompt (in this order).
+func (cmd RconShellCommand) password() (string, error) {
+	if password, ok := os.LookupEnv(rconPasswordEnv); ok {
+		return password, nil
+	}
+
+	if cmd.passwordFile != "" {
+		data, err := os.ReadFile(cmd.passwordFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	if !isTerminal(os.Stdin) {
+		return "", errors.New("no password given, use " + rconPasswordEnv + " or --password-file")
+	}
+
+	fmt.Fprint(os.Stderr, "Password : ")
+	restore, err := disableEcho(int(os.Stdin.Fd()))
+	if err == nil {
+		defer func() {
+			restore()
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+
+	return newLineEditor(os.Stdin, os.Stderr).readRawLine("")
+}
+
+func (RconShellCommand) basicOutput(response string) {
+	if response == "" {
+		return
+	}
+
+	if isTerminal(os.Stdout) {
+		fmt.Println(chat.LegacyToANSI(response))
+	} else {
+		fmt.Println(chat.StripLegacy(response))
+	}
+}
+
+func (RconShellCommand) jsonOutput(command string, response string) {
+	res := struct {
+		Command  string `json:"command"`
+		Response string `json:"response"`
+	}{
+		Command:  command,
+		Response: response,
+	}
+
+	json.NewEncoder(os.Stdout).Encode(res)
+}
+
+// completeVanillaCommand returns the possible completions of the last word of line : a command name, or its first argument.
+func completeVanillaCommand(line string) []string {
+	words := strings.Split(strings.TrimPrefix(line, "/"), " ")
+
+	var candidates []string
+	switch len(words) {
+	case 1:
+		for command := range vanillaCommands {
+			candidates = append(candidates, command)
+		}
+	case 2:
+		candidates = vanillaCommands[words[0]]
+	}
+
+	var completions []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, words[len(words)-1]) {
+			completions = append(completions, candidate)
+		}
+	}
+	sort.Strings(completions)
+
+	return completions
+}
@@ -1,18 +1,28 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/xrjr/mcutils/pkg/rcon"
 )
 
-type RconCommand struct{}
+type RconCommand struct {
+	script string
+}
+
+func (cmd *RconCommand) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&cmd.script, "script", "", "")
+}
 
 func (RconCommand) MinNumberOfArguments() int {
-	return 4
+	return 3
 }
 
 func (RconCommand) MaxNumberOfArguments() int {
@@ -20,7 +30,7 @@ func (RconCommand) MaxNumberOfArguments() int {
 }
 
 func (RconCommand) Usage() string {
-	return "<hostname> <port> <password> <command>"
+	return "[--script <file>] <hostname> <port> <password> [command]"
 }
 
 func (cmd RconCommand) Execute(params []string, jsonFormat bool) bool {
@@ -30,7 +40,27 @@ func (cmd RconCommand) Execute(params []string, jsonFormat bool) bool {
 		return false
 	}
 
-	response, err := rcon.Rcon(params[0], port, params[2], params[3])
+	if len(params) == 4 {
+		if cmd.script != "" {
+			fmt.Fprintln(os.Stderr, "A command and a script can't be given at the same time.")
+			return false
+		}
+
+		return cmd.executeCommand(params[0], port, params[2], params[3], jsonFormat)
+	}
+
+	commands, err := cmd.readScript()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
+		return false
+	}
+
+	return cmd.executeScript(params[0], port, params[2], commands, jsonFormat)
+}
+
+// executeCommand executes a single command.
+func (cmd RconCommand) executeCommand(hostname string, port int, password string, command string, jsonFormat bool) bool {
+	response, err := rcon.Rcon(hostname, port, password, command)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
 		return false
@@ -43,6 +73,56 @@ func (cmd RconCommand) Execute(params []string, jsonFormat bool) bool {
 	return cmd.basicOutput(response)
 }
 
+// executeScript executes commands one after another, and stops at the first failing one.
+func (cmd RconCommand) executeScript(hostname string, port int, password string, commands []string, jsonFormat bool) bool {
+	results, err := rcon.RconBatch(hostname, port, password, commands)
+
+	for _, result := range results {
+		if jsonFormat {
+			cmd.jsonResultOutput(result)
+		} else if result.Err == nil {
+			cmd.basicOutput(result.Response)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error : %s.\n", err.Error())
+		return false
+	}
+
+	return true
+}
+
+// readScript reads the commands of the script file, or of the standard input if no file (or "-") is given.
+// There is one command per line. Empty lines and lines starting with # are ignored, and the leading / of commands is optional.
+func (cmd RconCommand) readScript() ([]string, error) {
+	var r io.Reader = os.Stdin
+
+	if cmd.script != "" && cmd.script != "-" {
+		f, err := os.Open(cmd.script)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	var commands []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		commands = append(commands, strings.TrimPrefix(line, "/"))
+	}
+
+	return commands, scanner.Err()
+}
+
 func (RconCommand) basicOutput(response string) bool {
 	fmt.Println(response)
 
@@ -65,3 +145,28 @@ func (RconCommand) jsonOutput(response string) bool {
 
 	return true
 }
+
+// jsonResultOutput outputs the result of a command of a script, as a single line.
+func (RconCommand) jsonResultOutput(result rcon.CommandResult) bool {
+	res := struct {
+		Command  string `json:"command"`
+		Response string `json:"response"`
+		Error    string `json:"error,omitempty"`
+	}{
+		Command:  result.Command,
+		Response: result.Response,
+	}
+
+	if result.Err != nil {
+		res.Error = result.Err.Error()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	err := encoder.Encode(res)
+
+	if err != nil {
+		return false
+	}
+
+	return true
+}
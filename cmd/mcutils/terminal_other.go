@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package main
+
+import "errors"
+
+var errTerminalModeUnsupported error = errors.New("terminal mode can't be changed on this platform")
+
+// makeRaw is not supported on this platform : lines are read without editing.
+func makeRaw(fd int) (func(), error) {
+	return nil, errTerminalModeUnsupported
+}
+
+// disableEcho is not supported on this platform : passwords are displayed while typed.
+func disableEcho(fd int) (func(), error) {
+	return nil, errTerminalModeUnsupported
+}
@@ -2,25 +2,62 @@
 // This package is strictly compliant with the following documentation : https://minecraft.wiki/w/RakNet.
 package bedrock
 
+import (
+	"context"
+	"time"
+)
+
 // Ping returns the server infos, and latency of a minecraft bedrock server.
 // If an error occurred at any point of the process, an empty pong response, a latency of -1, and a non nil error are returned.
 func Ping(hostname string, port int) (UnconnectedPong, int, error) {
+	return PingContext(context.Background(), hostname, port)
+}
+
+// PingContext is the same as Ping, but the whole process is aborted as soon as ctx is done.
+func PingContext(ctx context.Context, hostname string, port int) (UnconnectedPong, int, error) {
 	client := NewClient(hostname, port)
 
-	err := client.Connect()
+	err := client.ConnectContext(ctx)
 	if err != nil {
 		return UnconnectedPong{}, -1, err
 	}
+	defer client.Disconnect()
 
-	unconnectedPong, latency, err := client.UnconnectedPing()
+	unconnectedPong, latency, err := client.UnconnectedPingContext(ctx)
 	if err != nil {
 		return UnconnectedPong{}, -1, err
 	}
 
-	err = client.Disconnect()
+	return unconnectedPong, latency, nil
+}
+
+// OpenConnection performs the raknet offline handshake with a minecraft bedrock server, and returns the negotiated connection parameters (see PingClient.OpenConnection).
+func OpenConnection(hostname string, port int) (ConnectionInfo, error) {
+	return OpenConnectionContext(context.Background(), hostname, port)
+}
+
+// OpenConnectionContext is the same as OpenConnection, but the whole process is aborted as soon as ctx is done.
+func OpenConnectionContext(ctx context.Context, hostname string, port int) (ConnectionInfo, error) {
+	client := NewClient(hostname, port)
+
+	err := client.ConnectContext(ctx)
 	if err != nil {
-		return UnconnectedPong{}, -1, err
+		return ConnectionInfo{}, err
 	}
+	defer client.Disconnect()
 
-	return unconnectedPong, latency, nil
+	return client.OpenConnectionContext(ctx)
+}
+
+// Discover broadcasts unconnected pings on the local network, and returns the bedrock servers (including LAN worlds) which answered within timeout.
+func Discover(timeout time.Duration) ([]DiscoveredServer, error) {
+	return DiscoverContext(context.Background(), timeout)
+}
+
+// DiscoverContext is the same as Discover, but the discovery ends as soon as ctx is done.
+func DiscoverContext(ctx context.Context, timeout time.Duration) ([]DiscoveredServer, error) {
+	discoverer := NewDiscoverer()
+	discoverer.Timeout = timeout
+
+	return discoverer.DiscoverContext(ctx)
 }
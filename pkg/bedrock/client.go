@@ -2,6 +2,7 @@ package bedrock
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"math/rand"
 	"strconv"
@@ -19,17 +20,19 @@ const (
 var (
 	ErrInvalidPacketType error = errors.New("invalid packet type")
 	ErrInvalidMagic      error = errors.New("invalid magic")
+	ErrStalePong         error = errors.New("pong answers another ping")
 
 	RaknetMagic = [16]byte{0x00, 0xff, 0xff, 0x00, 0xfe, 0xfe, 0xfe, 0xfe, 0xfd, 0xfd, 0xfd, 0xfd, 0x12, 0x34, 0x56, 0x78}
 )
 
 // generateUnconnectedPingRequest generates a networking.Output corresponding to an unconnected ping request.
+// The client timestamp is the current time in milliseconds, which the server echoes in its pong.
 func generateUnconnectedPingRequest(clientGUID uint64) networking.Output {
 	out := networking.NewOutput()
 
 	out.WriteByte(UnconnectedPingPacketID)
 
-	out.WriteBigEndianInt64(uint64(time.Now().Unix()))
+	out.WriteBigEndianInt64(uint64(time.Now().UnixMilli()))
 
 	out.WriteBytes(RaknetMagic[:])
 
@@ -158,13 +161,27 @@ type PingClient struct {
 	hostname   string
 	port       int
 	conn       *networking.UDPConn
+	attempts   int
 	ClientGUID uint64
 
 	// options
 	SkipSRVLookup                bool
 	ForceUDPProtocolForSRVLookup bool
+	DirectFallback               networking.DirectFallback
 	DialTimeout                  time.Duration
-	ReadTimeout                  time.Duration
+	// ReadTimeout limits each request, including its retransmissions, which are defined by Retry.
+	ReadTimeout time.Duration
+	Retry       networking.RetryPolicy
+	// Resolver is used for the SRV lookup, and Dialer to open the connection. They default to the ones of the net package.
+	Resolver networking.Resolver
+	Dialer   networking.Dialer
+
+	// RaknetProtocolVersion is the raknet protocol version sent in the open connection request 1. Defaults to RaknetProtocolVersion.
+	RaknetProtocolVersion byte
+	// MTUSizes are the MTU sizes tried by OpenConnection, in order. Defaults to DefaultMTUSizes.
+	MTUSizes []int
+	// MTUDiscoveryTimeout is the time waited for an answer to the open connection request 1, before trying the next MTU size. Defaults to DefaultMTUDiscoveryTimeout if 0.
+	MTUDiscoveryTimeout time.Duration
 }
 
 // NewClient returns a well-formed *PingClient.
@@ -180,19 +197,31 @@ func NewClient(hostname string, port int) *PingClient {
 		ForceUDPProtocolForSRVLookup: false,
 		DialTimeout:                  5 * time.Second,
 		ReadTimeout:                  5 * time.Second,
+		Retry:                        networking.DefaultRetryPolicy,
+		RaknetProtocolVersion:        RaknetProtocolVersion,
+		MTUSizes:                     DefaultMTUSizes,
+		MTUDiscoveryTimeout:          DefaultMTUDiscoveryTimeout,
 	}
 }
 
 // Connect establishes a connection via UDP.
 func (client *PingClient) Connect() error {
+	return client.ConnectContext(context.Background())
+}
+
+// ConnectContext is the same as Connect, but the connection attempt is aborted as soon as ctx is done.
+func (client *PingClient) ConnectContext(ctx context.Context) error {
 	if client.conn != nil {
 		return networking.ErrConnectionAlreadyEstablished
 	}
 
-	conn, err := networking.DialUDP(client.hostname, client.port, networking.DialUDPOptions{
+	conn, err := networking.DialUDPContext(ctx, client.hostname, client.port, networking.DialUDPOptions{
 		SkipSRVLookup:                client.SkipSRVLookup,
 		ForceUDPProtocolForSRVLookup: client.ForceUDPProtocolForSRVLookup,
+		DirectFallback:               client.DirectFallback,
 		DialTimeout:                  client.DialTimeout,
+		Resolver:                     client.Resolver,
+		Dialer:                       client.Dialer,
 	})
 	if err != nil {
 		return err
@@ -204,31 +233,54 @@ func (client *PingClient) Connect() error {
 
 // Handshake sends an unconncted ping request to the server, and returns the pong response informations.
 func (client *PingClient) UnconnectedPing() (UnconnectedPong, int, error) {
+	return client.UnconnectedPingContext(context.Background())
+}
+
+// UnconnectedPingContext is the same as UnconnectedPing, but the request is aborted as soon as ctx is done.
+func (client *PingClient) UnconnectedPingContext(ctx context.Context) (UnconnectedPong, int, error) {
 	if client.conn == nil {
 		return UnconnectedPong{}, -1, networking.ErrConnectionNotEstablished
 	}
 
-	unconnectedPingRequest := generateUnconnectedPingRequest(client.ClientGUID)
-
+	// Each attempt carries its own timestamp, so that the latency is the one of the attempt answered, and pongs to previous pings are discarded.
 	startTime := time.Now().UnixMilli()
 
-	// UDPConn reads are made in send method
-	err := client.conn.SetReadDeadline(client.ReadTimeout)
-	if err != nil {
-		return UnconnectedPong{}, -1, err
-	}
+	var pong *unconnectedPongResponse
+	var latency int
+	err := client.exchange(ctx, func() networking.Output {
+		return generateUnconnectedPingRequest(client.ClientGUID)
+	}, func(in networking.Input) error {
+		res, err := parseUnconnectedPongResponse(in)
+		if err != nil {
+			return err
+		}
 
-	unconnectedPongResponse, err := client.conn.Send(unconnectedPingRequest)
+		now := time.Now().UnixMilli()
+		if int64(res.ClientTimestamp) < startTime || int64(res.ClientTimestamp) > now {
+			return ErrStalePong
+		}
+		pong, latency = res, int(now-int64(res.ClientTimestamp))
+		return nil
+	})
 	if err != nil {
 		return UnconnectedPong{}, -1, err
 	}
 
-	unconnectedPong, err := parseUnconnectedPongResponse(unconnectedPongResponse)
-	if err != nil {
-		return UnconnectedPong{}, -1, err
-	}
+	return pong.unconnectedPong(), latency, nil
+}
+
+// exchange sends the request returned by request, and passes the responses to handle until one is accepted, retransmitting it as defined by the Retry option.
+// Responses rejected by handle are discarded.
+func (client *PingClient) exchange(ctx context.Context, request func() networking.Output, handle func(networking.Input) error) error {
+	attempts, err := client.conn.ExchangeContext(ctx, request, client.ReadTimeout, client.Retry, handle)
+	client.attempts = attempts
+	return err
+}
 
-	return unconnectedPong.unconnectedPong(), int(time.Now().UnixMilli() - startTime), nil
+// LastAttempts returns the number of times the last request has been sent, 1 meaning it hasn't been retransmitted.
+// The requests of the MTU discovery aren't counted, as they aren't retransmitted.
+func (client *PingClient) LastAttempts() int {
+	return client.attempts
 }
 
 // Disconnect closes the connection.
@@ -242,3 +294,12 @@ func (client *PingClient) Disconnect() error {
 	client.conn = nil
 	return err
 }
+
+// Target returns the address the client is connected to, which differs from its hostname and port if it comes from a SRV record.
+func (client *PingClient) Target() (networking.Target, error) {
+	if client.conn == nil {
+		return networking.Target{}, networking.ErrConnectionNotEstablished
+	}
+
+	return client.conn.Target(), nil
+}
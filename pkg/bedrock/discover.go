@@ -0,0 +1,200 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+const (
+	LANPortIPv4 int = 19132
+	LANPortIPv6 int = 19133
+)
+
+var (
+	ErrNoDiscoveryTarget error = errors.New("unconnected ping couldn't be sent to any discovery target")
+
+	// IPv4BroadcastAddr is the address to which LAN discovery pings are broadcast in IPv4.
+	IPv4BroadcastAddr = &net.UDPAddr{IP: net.IPv4bcast, Port: LANPortIPv4}
+	// IPv6MulticastAddr is the address to which LAN discovery pings are multicast in IPv6 (all nodes, on each interface).
+	IPv6MulticastAddr = &net.UDPAddr{IP: net.IPv6linklocalallnodes, Port: LANPortIPv6}
+)
+
+// DiscoveredServer is a server which answered a discovery ping.
+type DiscoveredServer struct {
+	// Address is the address the pong was sent from.
+	Address    string          `json:"address"`
+	ServerGUID uint64          `json:"serverGuid"`
+	Pong       UnconnectedPong `json:"pong"`
+	Latency    int             `json:"latency"`
+}
+
+// Discoverer discovers bedrock servers (including LAN worlds) on the local network, by broadcasting unconnected pings, as bedrock clients do.
+type Discoverer struct {
+	// options
+
+	// Timeout is the time window during which pongs are collected.
+	Timeout time.Duration
+	// Targets are the addresses unconnected pings are sent to. Defaults to IPv4BroadcastAddr and IPv6MulticastAddr.
+	// Link-local multicast addresses without zone are sent on each multicast interface.
+	Targets    []*net.UDPAddr
+	ClientGUID uint64
+}
+
+// NewDiscoverer returns a well-formed *Discoverer.
+// ClientGUID is set to a random value.
+func NewDiscoverer() *Discoverer {
+	return &Discoverer{
+		Timeout:    2 * time.Second,
+		Targets:    []*net.UDPAddr{IPv4BroadcastAddr, IPv6MulticastAddr},
+		ClientGUID: uint64(rand.Int()),
+	}
+}
+
+// Discover sends unconnected pings to the targets, and returns the servers which answered within the time window, sorted by address.
+// A server is reported once per source address and server GUID, even if it answered several pings.
+func (d *Discoverer) Discover() ([]DiscoveredServer, error) {
+	return d.DiscoverContext(context.Background())
+}
+
+// DiscoverContext is the same as Discover, but the time window ends as soon as ctx is done. The servers found so far are returned.
+func (d *Discoverer) DiscoverContext(ctx context.Context) ([]DiscoveredServer, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	targets := d.Targets
+	if targets == nil {
+		targets = []*net.UDPAddr{IPv4BroadcastAddr, IPv6MulticastAddr}
+	}
+
+	var ipv4Targets, ipv6Targets []*net.UDPAddr
+	for _, target := range targets {
+		if target.IP.To4() != nil {
+			ipv4Targets = append(ipv4Targets, target)
+		} else {
+			ipv6Targets = append(ipv6Targets, expandMulticastTarget(target)...)
+		}
+	}
+
+	var mutex sync.Mutex
+	servers := make(map[string]DiscoveredServer)
+	collect := func(server DiscoveredServer) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		key := server.Address + "/" + strconv.FormatUint(server.ServerGUID, 10)
+		if _, ok := servers[key]; !ok {
+			servers[key] = server
+		}
+	}
+
+	var wg sync.WaitGroup
+	sent := false
+	for network, networkTargets := range map[string][]*net.UDPAddr{"udp4": ipv4Targets, "udp6": ipv6Targets} {
+		if len(networkTargets) == 0 {
+			continue
+		}
+
+		conn, err := net.ListenPacket(network, ":0")
+		if err != nil {
+			continue
+		}
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
+
+		start := time.Now()
+		request := generateUnconnectedPingRequest(d.ClientGUID)
+		networkSent := false
+		for _, target := range networkTargets {
+			_, err := conn.WriteTo(request.Bytes(), target)
+			if err == nil {
+				networkSent = true
+			}
+		}
+		if !networkSent {
+			conn.Close()
+			continue
+		}
+		sent = true
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			readPongs(conn, start, collect)
+		}()
+	}
+
+	if !sent {
+		return nil, ErrNoDiscoveryTarget
+	}
+
+	wg.Wait()
+
+	res := make([]DiscoveredServer, 0, len(servers))
+	for _, server := range servers {
+		res = append(res, server)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Address != res[j].Address {
+			return res[i].Address < res[j].Address
+		}
+		return res[i].ServerGUID < res[j].ServerGUID
+	})
+
+	return res, nil
+}
+
+// readPongs reads the pongs received by conn until it is closed, and collects the corresponding servers.
+// Datagrams which aren't unconnected pongs are ignored.
+func readPongs(conn net.PacketConn, start time.Time, collect func(DiscoveredServer)) {
+	var buf [networking.MaximumUDPDatagramLength]byte
+	for {
+		n, addr, err := conn.ReadFrom(buf[:])
+		if err != nil {
+			return
+		}
+
+		pong, err := parseUnconnectedPongResponse(networking.NewInput(bytes.NewReader(buf[:n])))
+		if err != nil {
+			continue
+		}
+
+		collect(DiscoveredServer{
+			Address:    addr.String(),
+			ServerGUID: pong.ServerGUID,
+			Pong:       pong.unconnectedPong(),
+			Latency:    int(time.Since(start).Milliseconds()),
+		})
+	}
+}
+
+// expandMulticastTarget returns target on each up multicast interface if it is a link-local multicast address without zone, or target itself otherwise.
+func expandMulticastTarget(target *net.UDPAddr) []*net.UDPAddr {
+	if !target.IP.IsLinkLocalMulticast() || target.Zone != "" {
+		return []*net.UDPAddr{target}
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var res []*net.UDPAddr
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagMulticast != 0 {
+			res = append(res, &net.UDPAddr{IP: target.IP, Port: target.Port, Zone: iface.Name})
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,56 @@
+package bedrock
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDiscover(t *testing.T) {
+	guids := []uint64{1, 2}
+	ports := make([]int, len(guids))
+	for i, guid := range guids {
+		server := NewServer("", func() UnconnectedPong {
+			return UnconnectedPong{MOTD: "LAN world", MaxPlayers: 8}
+		})
+		server.GUID = guid
+		ports[i] = startTestServer(t, server)
+	}
+
+	discoverer := NewDiscoverer()
+	discoverer.Timeout = 200 * time.Millisecond
+	// The first server is pinged twice, but must be reported once.
+	discoverer.Targets = []*net.UDPAddr{
+		{IP: net.IPv4(127, 0, 0, 1), Port: ports[0]},
+		{IP: net.IPv4(127, 0, 0, 1), Port: ports[0]},
+		{IP: net.IPv4(127, 0, 0, 1), Port: ports[1]},
+	}
+
+	servers, err := discoverer.Discover()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if len(servers) != len(guids) {
+		t.Fatalf("Expected %d servers got %d.", len(guids), len(servers))
+	}
+
+	found := make(map[uint64]DiscoveredServer)
+	for _, server := range servers {
+		found[server.ServerGUID] = server
+	}
+	for i, guid := range guids {
+		server, ok := found[guid]
+		if !ok {
+			t.Errorf("Value %d: Expected server %d got none.", i, guid)
+			continue
+		}
+
+		expectedAddress := (&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: ports[i]}).String()
+		if server.Address != expectedAddress {
+			t.Errorf("Value %d: Expected %s got %s.", i, expectedAddress, server.Address)
+		}
+		if server.Pong.MOTD != "LAN world" || server.Pong.IPv4Port != ports[i] {
+			t.Errorf("Value %d: Expected LAN world on port %d got %v.", i, ports[i], server.Pong)
+		}
+	}
+}
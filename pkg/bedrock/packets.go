@@ -1,5 +1,17 @@
 package bedrock
 
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/xrjr/mcutils/pkg/chat"
+)
+
+const (
+	DefaultGameName string = "MCPE"
+)
+
 // unconnectedPongResponse is the type respresenting the response of the unconnected ping request.
 type unconnectedPongResponse struct {
 	PacketID        byte
@@ -54,3 +66,82 @@ type UnconnectedPong struct {
 	IPv4Port         int    `json:"ipv4Port"`
 	IPv6Port         int    `json:"ipv6Port"`
 }
+
+// Encode serialises the infos into the ;-separated string sent in unconnected pong responses, as bedrock dedicated servers do.
+// As ; is the separator, it is removed from the fields. An empty GameName is replaced with DefaultGameName.
+func (up UnconnectedPong) Encode() string {
+	gameName := up.GameName
+	if gameName == "" {
+		gameName = DefaultGameName
+	}
+
+	fields := []string{
+		gameName,
+		up.MOTD,
+		strconv.Itoa(up.ProtocolVersion),
+		up.MinecraftVersion,
+		strconv.Itoa(up.OnlinePlayers),
+		strconv.Itoa(up.MaxPlayers),
+		up.ServerID,
+		up.LevelName,
+		up.GameMode,
+		strconv.Itoa(up.GameModeNumeric),
+		strconv.Itoa(up.IPv4Port),
+		strconv.Itoa(up.IPv6Port),
+	}
+
+	var sb strings.Builder
+	for _, field := range fields {
+		sb.WriteString(strings.ReplaceAll(field, ";", ""))
+		sb.WriteByte(';')
+	}
+
+	return sb.String()
+}
+
+// MOTDComponent converts the MOTD, which may contain legacy formatting codes, into a text component.
+func (up UnconnectedPong) MOTDComponent() chat.Component {
+	return chat.FromLegacy(up.MOTD)
+}
+
+// unconnectedPingRequest is the type respresenting an unconnected ping request, as received by the server.
+type unconnectedPingRequest struct {
+	PacketID        byte
+	ClientTimestamp uint64
+	Magic           []byte
+	ClientGUID      uint64
+}
+
+// openConnectionReply1 is the type respresenting the response of the open connection request 1.
+type openConnectionReply1 struct {
+	PacketID   byte
+	Magic      []byte
+	ServerGUID uint64
+	Security   bool
+	Cookie     uint32
+	MTU        uint16
+}
+
+// openConnectionReply2 is the type respresenting the response of the open connection request 2.
+type openConnectionReply2 struct {
+	PacketID      byte
+	Magic         []byte
+	ServerGUID    uint64
+	ClientAddress *net.UDPAddr
+	MTU           uint16
+	Encryption    bool
+}
+
+// ConnectionInfo contains the parameters of a connection negotiated by the raknet offline handshake.
+type ConnectionInfo struct {
+	ServerGUID uint64 `json:"serverGuid"`
+	// MTU is the negotiated MTU size, in bytes.
+	MTU int `json:"mtu"`
+	// Security reports whether the server uses security (i.e. sent a cookie, echoed in the open connection request 2).
+	Security   bool `json:"security"`
+	Encryption bool `json:"encryption"`
+	// ProtocolVersion is the raknet protocol version : the one accepted by the server, or the one it expects if ErrIncompatibleProtocol is returned.
+	ProtocolVersion int `json:"protocolVersion"`
+	// ClientAddress is the address of the client, as seen by the server.
+	ClientAddress string `json:"clientAddress"`
+}
@@ -0,0 +1,375 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+const (
+	OpenConnectionRequest1PacketID      byte = 0x05
+	OpenConnectionReply1PacketID        byte = 0x06
+	OpenConnectionRequest2PacketID      byte = 0x07
+	OpenConnectionReply2PacketID        byte = 0x08
+	AlreadyConnectedPacketID            byte = 0x12
+	NoFreeIncomingConnectionsPacketID   byte = 0x14
+	ConnectionBannedPacketID            byte = 0x17
+	IncompatibleProtocolVersionPacketID byte = 0x19
+	IPRecentlyConnectedPacketID         byte = 0x1A
+
+	RaknetProtocolVersion byte = 11 // raknet protocol version of current bedrock servers
+	UDPHeaderSize         int  = 28 // size of the IP and UDP headers, which are part of the MTU
+
+	DefaultMTUDiscoveryTimeout time.Duration = time.Second
+)
+
+var (
+	ErrIncompatibleProtocol      error = errors.New("incompatible raknet protocol version")
+	ErrAlreadyConnected          error = errors.New("already connected")
+	ErrNoFreeIncomingConnections error = errors.New("no free incoming connections")
+	ErrConnectionBanned          error = errors.New("connection banned")
+	ErrIPRecentlyConnected       error = errors.New("ip recently connected")
+	ErrMTUDiscoveryFailed        error = errors.New("no answer to open connection request 1 with any MTU size")
+	ErrInvalidAddress            error = errors.New("invalid raknet address")
+
+	// DefaultMTUSizes are the MTU sizes tried by the MTU discovery, from the biggest to the smallest, as the raknet client does.
+	DefaultMTUSizes = []int{1492, 1200, 576}
+)
+
+// offlineMessageError returns the error corresponding to an offline message refusing the connection, if packetID is one of them.
+func offlineMessageError(packetID byte) error {
+	switch packetID {
+	case IncompatibleProtocolVersionPacketID:
+		return ErrIncompatibleProtocol
+	case AlreadyConnectedPacketID:
+		return ErrAlreadyConnected
+	case NoFreeIncomingConnectionsPacketID:
+		return ErrNoFreeIncomingConnections
+	case ConnectionBannedPacketID:
+		return ErrConnectionBanned
+	case IPRecentlyConnectedPacketID:
+		return ErrIPRecentlyConnected
+	}
+	return nil
+}
+
+// writeRaknetAddress writes an address, in the raknet format, to the output.
+// IPv4 addresses are written as the version (4), the bitwise NOT of each byte, and the port. IPv6 addresses are written as a sockaddr_in6 structure.
+func writeRaknetAddress(out *networking.Output, addr *net.UDPAddr) {
+	if ip4 := addr.IP.To4(); ip4 != nil {
+		out.WriteSingleByte(4)
+		for _, b := range ip4 {
+			out.WriteSingleByte(^b)
+		}
+		out.WriteBigEndianInt16(uint16(addr.Port))
+		return
+	}
+
+	out.WriteSingleByte(6)
+	out.WriteLittleEndianInt16(23) // AF_INET6, as defined on windows
+	out.WriteBigEndianInt16(uint16(addr.Port))
+	out.WriteBigEndianInt32(0) // flow info
+	out.WriteBytes(addr.IP.To16())
+	out.WriteBigEndianInt32(0) // scope id
+}
+
+// readRaknetAddress reads an address, in the raknet format, from the input.
+func readRaknetAddress(in networking.Input) (*net.UDPAddr, error) {
+	version, err := in.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch version {
+	case 4:
+		ip, err := in.ReadBytes(4)
+		if err != nil {
+			return nil, err
+		}
+		for i := range ip {
+			ip[i] = ^ip[i]
+		}
+
+		port, err := in.ReadBigEndianInt16()
+		if err != nil {
+			return nil, err
+		}
+
+		return &net.UDPAddr{IP: net.IP(ip), Port: int(port)}, nil
+	case 6:
+		raw, err := in.ReadBytes(28)
+		if err != nil {
+			return nil, err
+		}
+
+		return &net.UDPAddr{IP: net.IP(raw[8:24]), Port: int(binary.BigEndian.Uint16(raw[2:4]))}, nil
+	}
+
+	return nil, ErrInvalidAddress
+}
+
+// generateOpenConnectionRequest1 generates a networking.Output corresponding to an open connection request 1.
+// It is padded with zeros so that the whole datagram (including the IP and UDP headers) has the size of the MTU.
+func generateOpenConnectionRequest1(protocolVersion byte, mtu int) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteByte(OpenConnectionRequest1PacketID)
+
+	out.WriteBytes(RaknetMagic[:])
+
+	out.WriteByte(protocolVersion)
+
+	if padding := mtu - UDPHeaderSize - len(out.Bytes()); padding > 0 {
+		out.WriteBytes(make([]byte, padding))
+	}
+
+	return out
+}
+
+// parseOpenConnectionReply1 reads and parses a response (of type open connection reply 1) into an *openConnectionReply1.
+// If the server refuses the connection (e.g. because of an incompatible protocol), the corresponding error is returned, with the protocol version it expects.
+func parseOpenConnectionReply1(in networking.Input) (*openConnectionReply1, byte, error) {
+	var res openConnectionReply1
+
+	packetID, err := in.ReadByte()
+	if err != nil {
+		return nil, 0, err
+	}
+	res.PacketID = packetID
+
+	if res.PacketID == IncompatibleProtocolVersionPacketID {
+		protocolVersion, err := in.ReadByte()
+		if err != nil {
+			return nil, 0, err
+		}
+		return nil, protocolVersion, ErrIncompatibleProtocol
+	}
+	if err := offlineMessageError(res.PacketID); err != nil {
+		return nil, 0, err
+	}
+	if res.PacketID != OpenConnectionReply1PacketID {
+		return nil, 0, ErrInvalidPacketType
+	}
+
+	magic, err := in.ReadBytes(16)
+	if err != nil {
+		return nil, 0, err
+	}
+	res.Magic = magic
+	if !bytes.Equal(res.Magic, RaknetMagic[:]) {
+		return nil, 0, ErrInvalidMagic
+	}
+
+	serverGUID, err := in.ReadBigEndianInt64()
+	if err != nil {
+		return nil, 0, err
+	}
+	res.ServerGUID = serverGUID
+
+	security, err := in.ReadByte()
+	if err != nil {
+		return nil, 0, err
+	}
+	res.Security = security != 0
+
+	if res.Security {
+		cookie, err := in.ReadBigEndianInt32()
+		if err != nil {
+			return nil, 0, err
+		}
+		res.Cookie = cookie
+	}
+
+	mtu, err := in.ReadBigEndianInt16()
+	if err != nil {
+		return nil, 0, err
+	}
+	res.MTU = mtu
+
+	return &res, 0, nil
+}
+
+// generateOpenConnectionRequest2 generates a networking.Output corresponding to an open connection request 2.
+// If the server uses security, its cookie is echoed, without challenge.
+func generateOpenConnectionRequest2(reply1 *openConnectionReply1, serverAddress *net.UDPAddr, clientGUID uint64) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteByte(OpenConnectionRequest2PacketID)
+
+	out.WriteBytes(RaknetMagic[:])
+
+	if reply1.Security {
+		out.WriteBigEndianInt32(reply1.Cookie)
+		out.WriteSingleByte(0)
+	}
+
+	writeRaknetAddress(&out, serverAddress)
+
+	out.WriteBigEndianInt16(reply1.MTU)
+
+	out.WriteBigEndianInt64(clientGUID)
+
+	return out
+}
+
+// parseOpenConnectionReply2 reads and parses a response (of type open connection reply 2) into an *openConnectionReply2.
+func parseOpenConnectionReply2(in networking.Input) (*openConnectionReply2, error) {
+	var res openConnectionReply2
+
+	packetID, err := in.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	res.PacketID = packetID
+
+	if err := offlineMessageError(res.PacketID); err != nil {
+		return nil, err
+	}
+	if res.PacketID != OpenConnectionReply2PacketID {
+		return nil, ErrInvalidPacketType
+	}
+
+	magic, err := in.ReadBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	res.Magic = magic
+	if !bytes.Equal(res.Magic, RaknetMagic[:]) {
+		return nil, ErrInvalidMagic
+	}
+
+	serverGUID, err := in.ReadBigEndianInt64()
+	if err != nil {
+		return nil, err
+	}
+	res.ServerGUID = serverGUID
+
+	clientAddress, err := readRaknetAddress(in)
+	if err != nil {
+		return nil, err
+	}
+	res.ClientAddress = clientAddress
+
+	mtu, err := in.ReadBigEndianInt16()
+	if err != nil {
+		return nil, err
+	}
+	res.MTU = mtu
+
+	encryption, err := in.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	res.Encryption = encryption != 0
+
+	return &res, nil
+}
+
+// OpenConnection performs the raknet offline handshake (open connection requests 1 and 2), and returns the negotiated connection parameters.
+// The MTU is discovered by sending the open connection request 1 with each of MTUSizes, until the server answers.
+// If the server doesn't support RaknetProtocolVersion, ErrIncompatibleProtocol is returned, and ConnectionInfo.ProtocolVersion is the one it supports.
+// The connection is left at this point : the server will drop it after a while, as no connection request follows.
+func (client *PingClient) OpenConnection() (ConnectionInfo, error) {
+	return client.OpenConnectionContext(context.Background())
+}
+
+// OpenConnectionContext is the same as OpenConnection, but the handshake is aborted as soon as ctx is done.
+func (client *PingClient) OpenConnectionContext(ctx context.Context) (ConnectionInfo, error) {
+	if client.conn == nil {
+		return ConnectionInfo{}, networking.ErrConnectionNotEstablished
+	}
+
+	reply1, protocolVersion, err := client.discoverMTU(ctx)
+	if err == ErrIncompatibleProtocol {
+		return ConnectionInfo{ProtocolVersion: int(protocolVersion)}, err
+	}
+	if err != nil {
+		return ConnectionInfo{}, err
+	}
+
+	serverAddress, _ := client.conn.RemoteAddr().(*net.UDPAddr)
+	if serverAddress == nil {
+		serverAddress = &net.UDPAddr{IP: net.IPv4zero}
+	}
+
+	request2 := generateOpenConnectionRequest2(reply1, serverAddress, client.ClientGUID)
+
+	// Stale answers, such as duplicated open connection replies 1, are discarded. Offline messages refusing the connection are accepted.
+	var reply2 *openConnectionReply2
+	var reply2Err error
+	err = client.exchange(ctx, func() networking.Output {
+		return request2
+	}, func(in networking.Input) error {
+		reply2, reply2Err = parseOpenConnectionReply2(in)
+		if reply2Err == ErrInvalidPacketType {
+			return reply2Err
+		}
+		return nil
+	})
+	if err != nil {
+		return ConnectionInfo{}, err
+	}
+	if reply2Err != nil {
+		return ConnectionInfo{}, reply2Err
+	}
+
+	return ConnectionInfo{
+		ServerGUID:      reply2.ServerGUID,
+		MTU:             int(reply2.MTU),
+		Security:        reply1.Security,
+		Encryption:      reply2.Encryption,
+		ProtocolVersion: int(client.RaknetProtocolVersion),
+		ClientAddress:   reply2.ClientAddress.String(),
+	}, nil
+}
+
+// discoverMTU sends the open connection request 1 with decreasing MTU sizes, until the server answers.
+// If the protocol is incompatible, the protocol version expected by the server is returned with ErrIncompatibleProtocol.
+func (client *PingClient) discoverMTU(ctx context.Context) (*openConnectionReply1, byte, error) {
+	mtuSizes := client.MTUSizes
+	if len(mtuSizes) == 0 {
+		mtuSizes = DefaultMTUSizes
+	}
+
+	timeout := client.MTUDiscoveryTimeout
+	if timeout == 0 {
+		timeout = DefaultMTUDiscoveryTimeout
+	}
+
+	for _, mtu := range mtuSizes {
+		request1 := generateOpenConnectionRequest1(client.RaknetProtocolVersion, mtu)
+
+		// Each MTU size is tried once, and stray packets (e.g. late pongs) are discarded until the timeout.
+		var reply1 *openConnectionReply1
+		var protocolVersion byte
+		var reply1Err error
+		_, err := client.conn.ExchangeContext(ctx, func() networking.Output {
+			return request1
+		}, timeout, networking.RetryPolicy{Attempts: 1}, func(in networking.Input) error {
+			reply1, protocolVersion, reply1Err = parseOpenConnectionReply1(in)
+			if reply1Err == ErrInvalidPacketType {
+				return reply1Err
+			}
+			return nil
+		})
+
+		// Without answer, or if the datagram is too big to be sent, the next MTU size is tried.
+		var netErr net.Error
+		if ctx.Err() == nil && ((errors.As(err, &netErr) && netErr.Timeout()) || errors.Is(err, syscall.EMSGSIZE)) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return reply1, protocolVersion, reply1Err
+	}
+
+	return nil, 0, ErrMTUDiscoveryFailed
+}
@@ -0,0 +1,197 @@
+package bedrock
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+// testRaknetServerOptions customizes the behavior of the test raknet server.
+type testRaknetServerOptions struct {
+	// MaxMTU is the biggest MTU which reaches the server : bigger open connection requests 1 are dropped.
+	MaxMTU int
+	// ProtocolVersion is the only raknet protocol version accepted by the server.
+	ProtocolVersion byte
+	// Security makes the server send a cookie, which must be echoed.
+	Security bool
+	// StrayPong makes the server send an unconnected pong before each reply.
+	StrayPong bool
+}
+
+// startTestRaknetServer starts a minimal raknet server, answering open connection requests, and returns its port.
+func startTestRaknetServer(t *testing.T, guid uint64, options testRaknetServerOptions) int {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	const cookie uint32 = 0xCAFEBABE
+
+	go func() {
+		var buf [networking.MaximumUDPDatagramLength]byte
+		for {
+			n, addr, err := conn.ReadFrom(buf[:])
+			if err != nil {
+				return
+			}
+			in := networking.NewInput(bytes.NewReader(buf[1:n]))
+
+			out := networking.NewOutput()
+			switch buf[0] {
+			case OpenConnectionRequest1PacketID:
+				mtu := n + UDPHeaderSize
+				if mtu > options.MaxMTU {
+					continue
+				}
+
+				if buf[17] != options.ProtocolVersion {
+					out.WriteByte(IncompatibleProtocolVersionPacketID)
+					out.WriteByte(options.ProtocolVersion)
+					out.WriteBytes(RaknetMagic[:])
+					out.WriteBigEndianInt64(guid)
+					break
+				}
+
+				out.WriteByte(OpenConnectionReply1PacketID)
+				out.WriteBytes(RaknetMagic[:])
+				out.WriteBigEndianInt64(guid)
+				if options.Security {
+					out.WriteSingleByte(1)
+					out.WriteBigEndianInt32(cookie)
+				} else {
+					out.WriteSingleByte(0)
+				}
+				out.WriteBigEndianInt16(uint16(mtu))
+			case OpenConnectionRequest2PacketID:
+				in.ReadBytes(16)
+				if options.Security {
+					c, _ := in.ReadBigEndianInt32()
+					if c != cookie {
+						continue
+					}
+					in.ReadByte()
+				}
+				_, err := readRaknetAddress(in)
+				if err != nil {
+					continue
+				}
+				mtu, _ := in.ReadBigEndianInt16()
+
+				out.WriteByte(OpenConnectionReply2PacketID)
+				out.WriteBytes(RaknetMagic[:])
+				out.WriteBigEndianInt64(guid)
+				writeRaknetAddress(&out, addr.(*net.UDPAddr))
+				out.WriteBigEndianInt16(mtu)
+				out.WriteSingleByte(0)
+			default:
+				continue
+			}
+
+			if options.StrayPong {
+				pong := generateUnconnectedPongResponse(0, guid, "")
+				conn.WriteTo(pong.Bytes(), addr)
+			}
+			conn.WriteTo(out.Bytes(), addr)
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestRaknetAddress(t *testing.T) {
+	inputs := []*net.UDPAddr{
+		{IP: net.IPv4(192, 168, 1, 2).To4(), Port: 19132},
+		{IP: net.ParseIP("2001:db8::1"), Port: 19133},
+	}
+	expectedLengths := []int{7, 29}
+
+	for i := 0; i < len(inputs); i++ {
+		out := networking.NewOutput()
+		writeRaknetAddress(&out, inputs[i])
+		if len(out.Bytes()) != expectedLengths[i] {
+			t.Errorf("Value %d: Expected %d bytes got %d bytes.", i, expectedLengths[i], len(out.Bytes()))
+		}
+
+		res, err := readRaknetAddress(networking.NewInput(bytes.NewReader(out.Bytes())))
+		if err != nil || res.String() != inputs[i].String() {
+			t.Errorf("Value %d: Expected %v, <nil> got %v, %v.", i, inputs[i], res, err)
+		}
+	}
+}
+
+func TestOpenConnection(t *testing.T) {
+	inputs := []testRaknetServerOptions{
+		{MaxMTU: 1500, ProtocolVersion: RaknetProtocolVersion},
+		{MaxMTU: 1300, ProtocolVersion: RaknetProtocolVersion, Security: true},
+		{MaxMTU: 1000, ProtocolVersion: RaknetProtocolVersion},
+		{MaxMTU: 1500, ProtocolVersion: 10},
+		{MaxMTU: 500, ProtocolVersion: RaknetProtocolVersion},
+		{MaxMTU: 1500, ProtocolVersion: RaknetProtocolVersion, StrayPong: true},
+	}
+	expectedValues := []ConnectionInfo{
+		{ServerGUID: 42, MTU: 1492, ProtocolVersion: 11},
+		{ServerGUID: 42, MTU: 1200, ProtocolVersion: 11, Security: true},
+		{ServerGUID: 42, MTU: 576, ProtocolVersion: 11},
+		{ProtocolVersion: 10},
+		{},
+		{ServerGUID: 42, MTU: 1492, ProtocolVersion: 11},
+	}
+	expectedErrors := []error{nil, nil, nil, ErrIncompatibleProtocol, ErrMTUDiscoveryFailed, nil}
+
+	for i := 0; i < len(inputs); i++ {
+		port := startTestRaknetServer(t, 42, inputs[i])
+
+		client := NewClient("127.0.0.1", port)
+		client.MTUDiscoveryTimeout = 50 * time.Millisecond
+
+		err := client.Connect()
+		if err != nil {
+			t.Fatalf("Expected <nil> got %v.", err)
+		}
+
+		info, err := client.OpenConnection()
+		client.Disconnect()
+
+		if err != expectedErrors[i] {
+			t.Errorf("Error %d: Expected %v got %v.", i, expectedErrors[i], err)
+			continue
+		}
+
+		if err == nil {
+			// The client address is the one seen by the server.
+			if info.ClientAddress == "" {
+				t.Errorf("Value %d: Expected client address got none.", i)
+			}
+			info.ClientAddress = ""
+		}
+		if info != expectedValues[i] {
+			t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], info)
+		}
+	}
+}
+
+func TestOpenConnectionDefaultMTUDiscoveryTimeout(t *testing.T) {
+	port := startTestRaknetServer(t, 42, testRaknetServerOptions{MaxMTU: 1500, ProtocolVersion: RaknetProtocolVersion})
+
+	client := NewClient("127.0.0.1", port)
+	client.MTUDiscoveryTimeout = 0
+
+	err := client.Connect()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer client.Disconnect()
+
+	info, err := client.OpenConnection()
+	if err != nil || info.MTU != 1492 {
+		t.Errorf("Expected 1492, <nil> got %d, %v.", info.MTU, err)
+	}
+}
@@ -0,0 +1,206 @@
+package bedrock
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+const (
+	UnconnectedPingOpenConnectionsPacketID byte   = 0x02
+	DefaultServerAddr                      string = ":19132"
+)
+
+var (
+	ErrServerClosed error = errors.New("bedrock server is closed")
+)
+
+// parseUnconnectedPingRequest reads and parses a request (of type unconnected ping or unconnected ping open connections) into an *unconnectedPingRequest.
+func parseUnconnectedPingRequest(in networking.Input) (*unconnectedPingRequest, error) {
+	var req unconnectedPingRequest
+
+	packetID, err := in.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	req.PacketID = packetID
+	if req.PacketID != UnconnectedPingPacketID && req.PacketID != UnconnectedPingOpenConnectionsPacketID {
+		return nil, ErrInvalidPacketType
+	}
+
+	clientTimestamp, err := in.ReadBigEndianInt64()
+	if err != nil {
+		return nil, err
+	}
+	req.ClientTimestamp = clientTimestamp
+
+	magic, err := in.ReadBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	req.Magic = magic
+	if !bytes.Equal(req.Magic, RaknetMagic[:]) {
+		return nil, ErrInvalidMagic
+	}
+
+	clientGUID, err := in.ReadBigEndianInt64()
+	if err != nil {
+		return nil, err
+	}
+	req.ClientGUID = clientGUID
+
+	return &req, nil
+}
+
+// generateUnconnectedPongResponse generates a networking.Output corresponding to an unconnected pong response.
+func generateUnconnectedPongResponse(clientTimestamp uint64, serverGUID uint64, data string) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteByte(UnconnectedPongPacketID)
+
+	out.WriteBigEndianInt64(clientTimestamp)
+
+	out.WriteBigEndianInt64(serverGUID)
+
+	out.WriteBytes(RaknetMagic[:])
+
+	out.WriteRaknetString(data)
+
+	return out
+}
+
+// Server is a bedrock ping server, answering unconnected pings with an unconnected pong, like a bedrock dedicated server advertising itself.
+// Unconnected pings of type open connections are only answered while the server isn't full.
+type Server struct {
+	mutex  sync.Mutex
+	conn   net.PacketConn
+	closed bool
+
+	// options
+
+	// Addr is the UDP address listened by ListenAndServe. Defaults to DefaultServerAddr.
+	Addr string
+	// GUID is the server GUID sent in pong responses. NewServer sets it to a random value.
+	GUID uint64
+	// Pong returns the current infos of the server.
+	// An empty ServerID is replaced with the GUID, and null ports with the listened one.
+	Pong func() UnconnectedPong
+}
+
+// NewServer returns a well-formed *Server.
+// GUID is set to a random value.
+func NewServer(addr string, pong func() UnconnectedPong) *Server {
+	return &Server{
+		Addr: addr,
+		GUID: uint64(rand.Int63()),
+		Pong: pong,
+	}
+}
+
+// ListenAndServe listens on Addr, and serves requests until the server is closed. It always returns a non-nil error, ErrServerClosed after Close.
+func (server *Server) ListenAndServe() error {
+	addr := server.Addr
+	if addr == "" {
+		addr = DefaultServerAddr
+	}
+
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	return server.Serve(conn)
+}
+
+// Serve serves the requests received by conn until the server is closed. conn is closed when Serve returns.
+// It always returns a non-nil error, ErrServerClosed after Close.
+func (server *Server) Serve(conn net.PacketConn) error {
+	server.mutex.Lock()
+	if server.closed {
+		server.mutex.Unlock()
+		conn.Close()
+		return ErrServerClosed
+	}
+	server.conn = conn
+	server.mutex.Unlock()
+
+	defer conn.Close()
+
+	var buf [networking.MaximumUDPDatagramLength]byte
+	for {
+		n, addr, err := conn.ReadFrom(buf[:])
+		if err != nil {
+			server.mutex.Lock()
+			defer server.mutex.Unlock()
+
+			if server.closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		response, ok := server.handle(networking.NewInput(bytes.NewReader(buf[:n])))
+		if ok {
+			conn.WriteTo(response.Bytes(), addr)
+		}
+	}
+}
+
+// Close stops the server.
+func (server *Server) Close() error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.closed {
+		return ErrServerClosed
+	}
+	server.closed = true
+
+	if server.conn != nil {
+		return server.conn.Close()
+	}
+	return nil
+}
+
+// handle returns the response to a request, if it must be answered.
+func (server *Server) handle(in networking.Input) (networking.Output, bool) {
+	req, err := parseUnconnectedPingRequest(in)
+	if err != nil {
+		return networking.Output{}, false
+	}
+
+	pong := server.pong()
+	if req.PacketID == UnconnectedPingOpenConnectionsPacketID && pong.MaxPlayers > 0 && pong.OnlinePlayers >= pong.MaxPlayers {
+		return networking.Output{}, false
+	}
+
+	return generateUnconnectedPongResponse(req.ClientTimestamp, server.GUID, pong.Encode()), true
+}
+
+// pong returns the infos of the server, with defaults for the server id and the ports.
+func (server *Server) pong() UnconnectedPong {
+	var pong UnconnectedPong
+	if server.Pong != nil {
+		pong = server.Pong()
+	}
+
+	if pong.ServerID == "" {
+		pong.ServerID = strconv.FormatUint(server.GUID, 10)
+	}
+
+	if addr, ok := server.conn.LocalAddr().(*net.UDPAddr); ok {
+		if pong.IPv4Port == 0 {
+			pong.IPv4Port = addr.Port
+		}
+		if pong.IPv6Port == 0 {
+			pong.IPv6Port = addr.Port
+		}
+	}
+
+	return pong
+}
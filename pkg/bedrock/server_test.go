@@ -0,0 +1,188 @@
+package bedrock
+
+import (
+	"bytes"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+// startTestServer starts a bedrock ping server, and returns its port.
+func startTestServer(t *testing.T, server *Server) int {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	go server.Serve(conn)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestUnconnectedPongEncode(t *testing.T) {
+	inputs := []UnconnectedPong{
+		{},
+		{GameName: "MCEE", MOTD: "Hello; world", ProtocolVersion: 671, MinecraftVersion: "1.20.80", OnlinePlayers: 1, MaxPlayers: 10, ServerID: "42", LevelName: "Bedrock level", GameMode: "Survival", GameModeNumeric: 1, IPv4Port: 19132, IPv6Port: 19133},
+	}
+	expectedValues := []string{
+		"MCPE;;0;;0;0;;;;0;0;0;",
+		"MCEE;Hello world;671;1.20.80;1;10;42;Bedrock level;Survival;1;19132;19133;",
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		res := inputs[i].Encode()
+		if res != expectedValues[i] {
+			t.Errorf("Value %d: Expected %s got %s.", i, expectedValues[i], res)
+		}
+	}
+}
+
+func TestServerPing(t *testing.T) {
+	expectedValue := UnconnectedPong{
+		GameName:         "MCPE",
+		MOTD:             "§cMaintenance",
+		ProtocolVersion:  671,
+		MinecraftVersion: "1.20.80",
+		OnlinePlayers:    0,
+		MaxPlayers:       10,
+		ServerID:         "123",
+		LevelName:        "Placeholder",
+		GameMode:         "Survival",
+		GameModeNumeric:  1,
+	}
+
+	advertised := expectedValue
+	server := NewServer("", func() UnconnectedPong {
+		return advertised
+	})
+	server.GUID = 123
+	port := startTestServer(t, server)
+
+	pong, latency, err := Ping("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	expectedValue.IPv4Port = port
+	expectedValue.IPv6Port = port
+	if pong != expectedValue {
+		t.Errorf("Expected %v got %v.", expectedValue, pong)
+	}
+	if latency < 0 {
+		t.Errorf("Expected latency >= 0 got %d.", latency)
+	}
+}
+
+func TestServerOpenConnections(t *testing.T) {
+	var online int32
+	server := NewServer("", func() UnconnectedPong {
+		return UnconnectedPong{OnlinePlayers: int(atomic.LoadInt32(&online)), MaxPlayers: 1}
+	})
+	port := startTestServer(t, server)
+
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+
+	request := generateUnconnectedPingRequest(1)
+	request.Bytes()[0] = UnconnectedPingOpenConnectionsPacketID
+
+	// The server has an open slot, then is full.
+	inputs := []int32{0, 1}
+	expectedValues := []bool{true, false}
+
+	for i := 0; i < len(inputs); i++ {
+		atomic.StoreInt32(&online, inputs[i])
+
+		_, err = conn.Write(request.Bytes())
+		if err != nil {
+			t.Fatalf("Expected <nil> got %v.", err)
+		}
+
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		var buf [networking.MaximumUDPDatagramLength]byte
+		n, err := conn.Read(buf[:])
+
+		if (err == nil) != expectedValues[i] {
+			t.Errorf("Value %d: Expected answered %v got %v.", i, expectedValues[i], err)
+			continue
+		}
+		if err == nil {
+			_, err = parseUnconnectedPongResponse(networking.NewInput(bytes.NewReader(buf[:n])))
+			if err != nil {
+				t.Errorf("Value %d: Expected <nil> got %v.", i, err)
+			}
+		}
+	}
+}
+
+// lossyPacketConn is a packet connection which drops the first datagrams it receives, and sends a copy of each datagram with a stale client timestamp before it.
+type lossyPacketConn struct {
+	net.PacketConn
+	drop int
+}
+
+func (c *lossyPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		n, addr, err := c.PacketConn.ReadFrom(b)
+		if err != nil || c.drop == 0 {
+			return n, addr, err
+		}
+		c.drop--
+	}
+}
+
+func (c *lossyPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	stale := make([]byte, len(b))
+	copy(stale, b)
+	copy(stale[1:9], make([]byte, 8))
+	c.PacketConn.WriteTo(stale, addr)
+
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+func TestClientRetransmission(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	server := NewServer("", func() UnconnectedPong {
+		return UnconnectedPong{MOTD: "Lobby", MaxPlayers: 10}
+	})
+	go server.Serve(&lossyPacketConn{PacketConn: conn, drop: 1})
+	defer server.Close()
+
+	client := NewClient("127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port)
+	client.Retry = networking.RetryPolicy{Attempts: 3, Timeout: 50 * time.Millisecond, Backoff: 2}
+
+	err = client.Connect()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer client.Disconnect()
+
+	// The first ping is lost, and the stale pong sent before the valid one is discarded.
+	pong, latency, err := client.UnconnectedPing()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if pong.MOTD != "Lobby" {
+		t.Errorf("Expected Lobby got %s.", pong.MOTD)
+	}
+	if client.LastAttempts() != 2 {
+		t.Errorf("Expected 2 got %d.", client.LastAttempts())
+	}
+	if latency < 0 || latency >= 50 {
+		t.Errorf("Expected latency of the second attempt got %d.", latency)
+	}
+}
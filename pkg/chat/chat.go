@@ -0,0 +1,226 @@
+// chat package implements the minecraft JSON text components (a.k.a. chat components), used for example in server list ping MOTDs.
+// This package is compliant with the following documentation : https://minecraft.wiki/w/Text_component_format.
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidComponent error = errors.New("invalid text component")
+)
+
+// Component is a JSON text component. A component holds some content (Text, Translate or Keybind), an optional style, and children (Extra) inheriting its style.
+// Style booleans are pointers, as an explicit false overrides the value inherited from the parent, while nil doesn't.
+type Component struct {
+	Text      string      `json:"text,omitempty"`
+	Translate string      `json:"translate,omitempty"`
+	Fallback  string      `json:"fallback,omitempty"`
+	With      []Component `json:"with,omitempty"`
+	Keybind   string      `json:"keybind,omitempty"`
+
+	Color         string `json:"color,omitempty"`
+	Font          string `json:"font,omitempty"`
+	Bold          *bool  `json:"bold,omitempty"`
+	Italic        *bool  `json:"italic,omitempty"`
+	Underlined    *bool  `json:"underlined,omitempty"`
+	Strikethrough *bool  `json:"strikethrough,omitempty"`
+	Obfuscated    *bool  `json:"obfuscated,omitempty"`
+
+	Insertion  string          `json:"insertion,omitempty"`
+	ClickEvent json.RawMessage `json:"clickEvent,omitempty"`
+	HoverEvent json.RawMessage `json:"hoverEvent,omitempty"`
+
+	Extra []Component `json:"extra,omitempty"`
+}
+
+// rawComponent has the same fields as Component, without its JSON methods.
+type rawComponent Component
+
+// Parse parses a JSON text component. See Component.UnmarshalJSON for accepted forms.
+func Parse(data []byte) (Component, error) {
+	var c Component
+
+	err := json.Unmarshal(data, &c)
+	if err != nil {
+		return Component{}, err
+	}
+
+	return c, nil
+}
+
+// UnmarshalJSON decodes a JSON text component, which can either be :
+//   - a string, which is the same as a component with only text
+//   - an array, in which the first element is the parent of all the following ones
+//   - an object
+//   - a number or a boolean, which is the same as a component with only their textual representation as text
+func (c *Component) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return ErrInvalidComponent
+	}
+
+	switch data[0] {
+	case '"':
+		var text string
+		err := json.Unmarshal(data, &text)
+		if err != nil {
+			return err
+		}
+		*c = Component{Text: text}
+
+	case '[':
+		var components []Component
+		err := json.Unmarshal(data, &components)
+		if err != nil {
+			return err
+		}
+		if len(components) == 0 {
+			return ErrInvalidComponent
+		}
+		*c = components[0]
+		c.Extra = append(c.Extra, components[1:]...)
+
+	case '{':
+		var raw rawComponent
+		err := json.Unmarshal(data, &raw)
+		if err != nil {
+			return err
+		}
+		*c = Component(raw)
+
+	case 'n':
+		*c = Component{}
+
+	default:
+		var value interface{}
+		err := json.Unmarshal(data, &value)
+		if err != nil {
+			return err
+		}
+		*c = Component{Text: string(data)}
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the component as a JSON object.
+// The text field is always present unless the component is a translate or a keybind one, as it is required by the minecraft client.
+func (c Component) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(rawComponent(c))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Text != "" || c.Translate != "" || c.Keybind != "" {
+		return data, nil
+	}
+
+	if string(data) == "{}" {
+		return []byte(`{"text":""}`), nil
+	}
+
+	return append([]byte(`{"text":"",`), data[1:]...), nil
+}
+
+// String returns the plain text of the component.
+func (c Component) String() string {
+	return c.PlainText()
+}
+
+// content returns the spans of the component own content (i.e. without its children), using the given style.
+func (c Component) content(style Style) []Span {
+	if c.Translate != "" {
+		return c.translation(style)
+	}
+
+	if c.Keybind != "" {
+		return parseLegacy(c.Keybind, style)
+	}
+
+	return parseLegacy(c.Text, style)
+}
+
+// translation returns the spans of a translate component. As translations are not available, the fallback is used if present, else the translation key.
+// Placeholders (%s and %1$s) of the format are replaced by the With arguments.
+func (c Component) translation(style Style) []Span {
+	format := c.Translate
+	if c.Fallback != "" {
+		format = c.Fallback
+	}
+
+	var spans []Span
+	var literal strings.Builder
+	var next int
+
+	flush := func() {
+		spans = append(spans, parseLegacy(literal.String(), style)...)
+		literal.Reset()
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 >= len(format) {
+			literal.WriteByte(format[i])
+			continue
+		}
+
+		if format[i+1] == '%' {
+			literal.WriteByte('%')
+			i++
+			continue
+		}
+
+		index := -1
+		end := i + 1
+
+		if format[end] == 's' {
+			index = next
+			next++
+		} else {
+			n := 0
+			for end < len(format) && format[end] >= '0' && format[end] <= '9' {
+				n = n*10 + int(format[end]-'0')
+				end++
+			}
+			if end+1 < len(format) && end > i+1 && format[end] == '$' && format[end+1] == 's' {
+				index = n - 1
+				end++
+			}
+		}
+
+		if index < 0 {
+			literal.WriteByte(format[i])
+			continue
+		}
+
+		flush()
+		if index < len(c.With) {
+			spans = append(spans, c.With[index].spans(style)...)
+		}
+		i = end
+	}
+	flush()
+
+	return spans
+}
+
+// Spans flattens the component tree into a list of styled spans of text, in display order.
+// Style inheritance is resolved, and legacy formatting codes embedded in texts are applied.
+func (c Component) Spans() []Span {
+	return mergeSpans(c.spans(Style{}))
+}
+
+// spans returns the spans of the component and its children, inheriting the parent style.
+func (c Component) spans(parent Style) []Span {
+	style := parent.apply(c)
+
+	spans := c.content(style)
+	for _, extra := range c.Extra {
+		spans = append(spans, extra.spans(style)...)
+	}
+
+	return spans
+}
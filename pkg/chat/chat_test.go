@@ -0,0 +1,158 @@
+package chat
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParsePlainText(t *testing.T) {
+	inputs := []string{
+		`"A Minecraft Server"`,
+		`{"text": "A Minecraft Server"}`,
+		`{"text": "A ", "extra": ["Minecraft", {"text": " Server", "bold": true}]}`,
+		`["A ", {"text": "Minecraft"}, " Server"]`,
+		`{"text": "", "extra": [{"text": "A §aMinecraft§r Server"}]}`,
+		`{"translate": "%s Minecraft %s", "with": ["A", {"text": "Server"}]}`,
+		`{"translate": "%2$s Minecraft %1$s", "with": ["Server", "A"]}`,
+		`{"translate": "unknown.key", "fallback": "A Minecraft %s", "with": ["Server"]}`,
+		`42`,
+	}
+	expectedValues := []string{
+		"A Minecraft Server",
+		"A Minecraft Server",
+		"A Minecraft Server",
+		"A Minecraft Server",
+		"A Minecraft Server",
+		"A Minecraft Server",
+		"A Minecraft Server",
+		"A Minecraft Server",
+		"42",
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		c, err := Parse([]byte(inputs[i]))
+		if err != nil {
+			t.Errorf("Error %d: Expected <nil> got %v.", i, err)
+			continue
+		}
+
+		if res := c.PlainText(); res != expectedValues[i] {
+			t.Errorf("Value %d: Expected %q got %q.", i, expectedValues[i], res)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	inputs := []string{
+		`[]`,
+		`{"text": 42}`,
+		`{`,
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		_, err := Parse([]byte(inputs[i]))
+		if err == nil {
+			t.Errorf("Error %d: Expected true got false.", i)
+		}
+	}
+}
+
+func TestSpans(t *testing.T) {
+	inputs := []string{
+		`{"text": "a", "color": "red", "bold": true, "extra": [{"text": "b", "bold": false}, {"text": "c", "color": "#00ff00"}]}`,
+		`"§aa§lb§cc§rd"`,
+		`{"text": "a§x§1§2§3§4§5§6b", "italic": true}`,
+		`{"text": "x", "color": "gold", "extra": ["§oy§rz"]}`,
+	}
+	expectedValues := [][]Span{
+		{
+			{Text: "a", Style: Style{Color: "red", Bold: true}},
+			{Text: "b", Style: Style{Color: "red"}},
+			{Text: "c", Style: Style{Color: "#00FF00", Bold: true}},
+		},
+		{
+			{Text: "a", Style: Style{Color: "green"}},
+			{Text: "b", Style: Style{Color: "green", Bold: true}},
+			{Text: "c", Style: Style{Color: "red"}},
+			{Text: "d", Style: Style{}},
+		},
+		{
+			{Text: "a", Style: Style{Italic: true}},
+			{Text: "b", Style: Style{Color: "#123456"}},
+		},
+		{
+			{Text: "x", Style: Style{Color: "gold"}},
+			{Text: "y", Style: Style{Color: "gold", Italic: true}},
+			{Text: "z", Style: Style{Color: "gold"}},
+		},
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		c, err := Parse([]byte(inputs[i]))
+		if err != nil {
+			t.Errorf("Error %d: Expected <nil> got %v.", i, err)
+			continue
+		}
+
+		res := c.Spans()
+		if len(res) != len(expectedValues[i]) {
+			t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], res)
+			continue
+		}
+		for j := range res {
+			if res[j] != expectedValues[i][j] {
+				t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], res)
+				break
+			}
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	c, err := Parse([]byte(`{"text": "A ", "extra": [{"text": "<Server>", "color": "gold", "bold": true}, {"text": "!", "color": "#123456"}]}`))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	expectedLegacy := "A §6§l<Server>§8!"
+	if res := c.Legacy(); res != expectedLegacy {
+		t.Errorf("Legacy: Expected %q got %q.", expectedLegacy, res)
+	}
+
+	expectedANSI := "A \x1b[0m\x1b[33;1m<Server>\x1b[0m\x1b[38;2;18;52;86m!\x1b[0m"
+	if res := c.ANSI(); res != expectedANSI {
+		t.Errorf("ANSI: Expected %q got %q.", expectedANSI, res)
+	}
+
+	expectedHTML := `A <span style="color: #FFAA00; font-weight: bold">&lt;Server&gt;</span><span style="color: #123456">!</span>`
+	if res := c.HTML(); res != expectedHTML {
+		t.Errorf("HTML: Expected %q got %q.", expectedHTML, res)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	inputs := []Component{
+		{},
+		{Color: "red"},
+		{Text: "a"},
+		{Translate: "key"},
+	}
+	expectedValues := []string{
+		`{"text":""}`,
+		`{"text":"","color":"red"}`,
+		`{"text":"a"}`,
+		`{"translate":"key"}`,
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		res, err := json.Marshal(inputs[i])
+		if err != nil {
+			t.Errorf("Error %d: Expected <nil> got %v.", i, err)
+			continue
+		}
+
+		if string(res) != expectedValues[i] {
+			t.Errorf("Value %d: Expected %s got %s.", i, expectedValues[i], res)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package chat
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// LegacyFormattingCode is the character prefixing legacy formatting codes (e.g. §a for green).
+	LegacyFormattingCode rune = '§'
+)
+
+// ParseLegacy splits a string containing legacy formatting codes (e.g. §a for green) into styled spans.
+// A color code resets the formatting codes, and the reset code (§r) goes back to the default style.
+// Colors of the form §x§R§R§G§G§B§B (hex colors, as sent by some server softwares) are supported. Unknown codes are dropped.
+func ParseLegacy(s string) []Span {
+	return mergeSpans(parseLegacy(s, Style{}))
+}
+
+// StripLegacy removes all legacy formatting codes from a string.
+func StripLegacy(s string) string {
+	var sb strings.Builder
+
+	for _, span := range ParseLegacy(s) {
+		sb.WriteString(span.Text)
+	}
+
+	return sb.String()
+}
+
+// LegacyToANSI converts a string containing legacy formatting codes into a string formatted with ANSI escape sequences, to be displayed in a terminal.
+func LegacyToANSI(s string) string {
+	return ansiFromSpans(ParseLegacy(s))
+}
+
+// LegacyToHTML converts a string containing legacy formatting codes into an HTML fragment.
+func LegacyToHTML(s string) string {
+	return htmlFromSpans(ParseLegacy(s))
+}
+
+// FromLegacy converts a string containing legacy formatting codes into a text component.
+// The returned component has an empty text, and one child per styled span of text.
+func FromLegacy(s string) Component {
+	spans := ParseLegacy(s)
+
+	c := Component{
+		Extra: make([]Component, 0, len(spans)),
+	}
+
+	for _, span := range spans {
+		c.Extra = append(c.Extra, span.component())
+	}
+
+	return c
+}
+
+// component converts the span into a text component. Only styles which are set are written, as the component doesn't inherit any style.
+func (span Span) component() Component {
+	c := Component{
+		Text:  span.Text,
+		Color: span.Style.Color,
+	}
+
+	flags := []struct {
+		set   bool
+		field **bool
+	}{
+		{span.Style.Bold, &c.Bold},
+		{span.Style.Italic, &c.Italic},
+		{span.Style.Underlined, &c.Underlined},
+		{span.Style.Strikethrough, &c.Strikethrough},
+		{span.Style.Obfuscated, &c.Obfuscated},
+	}
+
+	for _, flag := range flags {
+		if flag.set {
+			set := true
+			*flag.field = &set
+		}
+	}
+
+	return c
+}
+
+// parseLegacy splits a string containing legacy formatting codes into styled spans, starting with the base style.
+// A color code resets the formatting codes, while the reset code (§r) goes back to the base style.
+// Colors of the form §x§R§R§G§G§B§B (hex colors, as sent by some server softwares) are supported. Unknown codes are dropped.
+func parseLegacy(s string, base Style) []Span {
+	if !strings.ContainsRune(s, LegacyFormattingCode) {
+		return []Span{{Text: s, Style: base}}
+	}
+
+	var spans []Span
+	var text strings.Builder
+	var style Style = base
+
+	flush := func() {
+		if text.Len() > 0 {
+			spans = append(spans, Span{Text: text.String(), Style: style})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		if r != LegacyFormattingCode {
+			text.WriteRune(r)
+			continue
+		}
+
+		if i >= len(s) {
+			break
+		}
+
+		code := lowerASCII(s[i])
+		_, size = utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		flush()
+
+		if code == 'x' {
+			if color, n := parseLegacyHexColor(s[i:]); n > 0 {
+				style = Style{Color: color}
+				i += n
+			}
+			continue
+		}
+
+		if c, ok := colorByCode(code); ok {
+			style = Style{Color: c.Name}
+			continue
+		}
+
+		switch code {
+		case 'k':
+			style.Obfuscated = true
+		case 'l':
+			style.Bold = true
+		case 'm':
+			style.Strikethrough = true
+		case 'n':
+			style.Underlined = true
+		case 'o':
+			style.Italic = true
+		case 'r':
+			style = base
+		}
+	}
+	flush()
+
+	return spans
+}
+
+// parseLegacyHexColor parses the §R§R§G§G§B§B part of a legacy hex color, and returns the color and the number of bytes read.
+// If s doesn't start with a valid hex color, n is 0.
+func parseLegacyHexColor(s string) (color string, n int) {
+	var digits [6]byte
+	prefix := string(LegacyFormattingCode)
+
+	for d := 0; d < 6; d++ {
+		if !strings.HasPrefix(s[n:], prefix) || n+len(prefix) >= len(s) {
+			return "", 0
+		}
+		n += len(prefix)
+
+		digit := lowerASCII(s[n])
+		if !(digit >= '0' && digit <= '9' || digit >= 'a' && digit <= 'f') {
+			return "", 0
+		}
+		digits[d] = digit
+		n++
+	}
+
+	return "#" + strings.ToUpper(string(digits[:])), n
+}
+
+// lowerASCII returns the lower case version of an ASCII letter, or b itself.
+func lowerASCII(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + ('a' - 'A')
+	}
+	return b
+}
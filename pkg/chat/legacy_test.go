@@ -0,0 +1,115 @@
+package chat
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseLegacy(t *testing.T) {
+	inputs := []string{
+		"A Minecraft Server",
+		"§aA §lMinecraft§r Server",
+		"§Aa§Lb",
+		"a§",
+		"a§zb§x§f§fc",
+		"§x§F§F§0§0§0§0red§kobf",
+	}
+	expectedValues := [][]Span{
+		{{Text: "A Minecraft Server"}},
+		{
+			{Text: "A ", Style: Style{Color: "green"}},
+			{Text: "Minecraft", Style: Style{Color: "green", Bold: true}},
+			{Text: " Server"},
+		},
+		{
+			{Text: "a", Style: Style{Color: "green"}},
+			{Text: "b", Style: Style{Color: "green", Bold: true}},
+		},
+		{{Text: "a"}},
+		{
+			{Text: "ab"},
+			{Text: "c", Style: Style{Color: "white"}},
+		},
+		{
+			{Text: "red", Style: Style{Color: "#FF0000"}},
+			{Text: "obf", Style: Style{Color: "#FF0000", Obfuscated: true}},
+		},
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		res := ParseLegacy(inputs[i])
+
+		if len(res) != len(expectedValues[i]) {
+			t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], res)
+			continue
+		}
+		for j := range res {
+			if res[j] != expectedValues[i][j] {
+				t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], res)
+				break
+			}
+		}
+	}
+}
+
+func TestStripLegacy(t *testing.T) {
+	inputs := []string{
+		"A Minecraft Server",
+		"§aA §l§nMinecraft§r Server",
+		"§x§1§2§3§4§5§6A Minecraft Server§",
+	}
+	expectedValue := "A Minecraft Server"
+
+	for i := 0; i < len(inputs); i++ {
+		if res := StripLegacy(inputs[i]); res != expectedValue {
+			t.Errorf("Value %d: Expected %q got %q.", i, expectedValue, res)
+		}
+	}
+}
+
+func TestLegacyToANSI(t *testing.T) {
+	inputs := []string{
+		"plain",
+		"§cred§r plain",
+		"§l§obold italic",
+	}
+	expectedValues := []string{
+		"plain",
+		"\x1b[0m\x1b[91mred\x1b[0m plain",
+		"\x1b[0m\x1b[1;3mbold italic\x1b[0m",
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		if res := LegacyToANSI(inputs[i]); res != expectedValues[i] {
+			t.Errorf("Value %d: Expected %q got %q.", i, expectedValues[i], res)
+		}
+	}
+}
+
+func TestFromLegacy(t *testing.T) {
+	inputs := []string{
+		"plain",
+		"§6§lgold§r plain",
+	}
+	expectedValues := []string{
+		`{"text":"","extra":[{"text":"plain"}]}`,
+		`{"text":"","extra":[{"text":"gold","color":"gold","bold":true},{"text":" plain"}]}`,
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		c := FromLegacy(inputs[i])
+
+		res, err := json.Marshal(c)
+		if err != nil {
+			t.Errorf("Error %d: Expected <nil> got %v.", i, err)
+			continue
+		}
+		if string(res) != expectedValues[i] {
+			t.Errorf("Value %d: Expected %s got %s.", i, expectedValues[i], res)
+		}
+
+		if c.Legacy() != inputs[i] {
+			t.Errorf("Legacy %d: Expected %q got %q.", i, inputs[i], c.Legacy())
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package chat
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const (
+	ansiReset string = "\x1b[0m"
+)
+
+// PlainText returns the text of the component and its children, without any formatting.
+func (c Component) PlainText() string {
+	var sb strings.Builder
+
+	for _, span := range c.Spans() {
+		sb.WriteString(span.Text)
+	}
+
+	return sb.String()
+}
+
+// Legacy returns the text of the component and its children, formatted with legacy formatting codes (e.g. §a for green).
+// As legacy formatting only supports the 16 minecraft colors, hex colors are replaced by the nearest one.
+func (c Component) Legacy() string {
+	return legacyFromSpans(c.Spans())
+}
+
+// ANSI returns the text of the component and its children, formatted with ANSI escape sequences to be displayed in a terminal.
+// Hex colors are rendered using 24-bit colors sequences.
+func (c Component) ANSI() string {
+	return ansiFromSpans(c.Spans())
+}
+
+// HTML returns the text of the component and its children, formatted as an HTML fragment.
+// Styled spans of text are rendered as <span> elements with an inline style, and line breaks are rendered as <br>.
+func (c Component) HTML() string {
+	return htmlFromSpans(c.Spans())
+}
+
+// legacyFromSpans formats spans with legacy formatting codes.
+func legacyFromSpans(spans []Span) string {
+	var sb strings.Builder
+	var previous Style
+
+	for _, span := range spans {
+		if span.Style != previous {
+			writeLegacyStyle(&sb, span.Style)
+			previous = span.Style
+		}
+		sb.WriteString(span.Text)
+	}
+
+	return sb.String()
+}
+
+// writeLegacyStyle writes the legacy formatting codes of the style s. A color (or reset) code is always written first, as it resets formatting codes.
+func writeLegacyStyle(sb *strings.Builder, s Style) {
+	sb.WriteRune(LegacyFormattingCode)
+	if rgb, ok := s.RGB(); ok {
+		sb.WriteByte(nearestColor(rgb).Code)
+	} else {
+		sb.WriteByte('r')
+	}
+
+	flags := []struct {
+		set  bool
+		code byte
+	}{
+		{s.Obfuscated, 'k'},
+		{s.Bold, 'l'},
+		{s.Strikethrough, 'm'},
+		{s.Underlined, 'n'},
+		{s.Italic, 'o'},
+	}
+
+	for _, flag := range flags {
+		if flag.set {
+			sb.WriteRune(LegacyFormattingCode)
+			sb.WriteByte(flag.code)
+		}
+	}
+}
+
+// ansiFromSpans formats spans with ANSI escape sequences.
+func ansiFromSpans(spans []Span) string {
+	var sb strings.Builder
+	var previous Style
+
+	for _, span := range spans {
+		if span.Style != previous {
+			sb.WriteString(ansiReset)
+			sb.WriteString(ansiStyle(span.Style))
+			previous = span.Style
+		}
+		sb.WriteString(span.Text)
+	}
+
+	if previous != (Style{}) {
+		sb.WriteString(ansiReset)
+	}
+
+	return sb.String()
+}
+
+// ansiStyle returns the ANSI escape sequence corresponding to the style s, or an empty string for the default style.
+func ansiStyle(s Style) string {
+	var params []string
+
+	if c, ok := colorByName(s.Color); ok {
+		params = append(params, fmt.Sprint(c.ANSI))
+	} else if rgb, ok := parseHexColor(s.Color); ok {
+		params = append(params, fmt.Sprintf("38;2;%d;%d;%d", rgb>>16&0xFF, rgb>>8&0xFF, rgb&0xFF))
+	}
+
+	if s.Bold {
+		params = append(params, "1")
+	}
+	if s.Italic {
+		params = append(params, "3")
+	}
+	if s.Underlined {
+		params = append(params, "4")
+	}
+	if s.Strikethrough {
+		params = append(params, "9")
+	}
+
+	if len(params) == 0 {
+		return ""
+	}
+
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// htmlFromSpans formats spans as an HTML fragment.
+func htmlFromSpans(spans []Span) string {
+	var sb strings.Builder
+
+	for _, span := range spans {
+		text := strings.ReplaceAll(html.EscapeString(span.Text), "\n", "<br>")
+
+		css := htmlStyle(span.Style)
+		if css == "" {
+			sb.WriteString(text)
+			continue
+		}
+
+		fmt.Fprintf(&sb, `<span style="%s">%s</span>`, css, text)
+	}
+
+	return sb.String()
+}
+
+// htmlStyle returns the inline CSS corresponding to the style s, or an empty string for the default style.
+func htmlStyle(s Style) string {
+	var declarations []string
+
+	if rgb, ok := s.RGB(); ok {
+		declarations = append(declarations, fmt.Sprintf("color: #%06X", rgb))
+	}
+	if s.Bold {
+		declarations = append(declarations, "font-weight: bold")
+	}
+	if s.Italic {
+		declarations = append(declarations, "font-style: italic")
+	}
+
+	var decorations []string
+	if s.Underlined {
+		decorations = append(decorations, "underline")
+	}
+	if s.Strikethrough {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		declarations = append(declarations, "text-decoration: "+strings.Join(decorations, " "))
+	}
+
+	return strings.Join(declarations, "; ")
+}
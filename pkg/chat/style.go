@@ -0,0 +1,164 @@
+package chat
+
+import (
+	"strconv"
+	"strings"
+)
+
+// namedColor is one of the 16 colors of minecraft, which can be used by name in text components, or by code in legacy formatting.
+type namedColor struct {
+	Name string
+	Code byte
+	RGB  uint32
+	ANSI int
+}
+
+// namedColors are the 16 colors of minecraft, ordered by legacy code.
+var namedColors = [16]namedColor{
+	{"black", '0', 0x000000, 30},
+	{"dark_blue", '1', 0x0000AA, 34},
+	{"dark_green", '2', 0x00AA00, 32},
+	{"dark_aqua", '3', 0x00AAAA, 36},
+	{"dark_red", '4', 0xAA0000, 31},
+	{"dark_purple", '5', 0xAA00AA, 35},
+	{"gold", '6', 0xFFAA00, 33},
+	{"gray", '7', 0xAAAAAA, 37},
+	{"dark_gray", '8', 0x555555, 90},
+	{"blue", '9', 0x5555FF, 94},
+	{"green", 'a', 0x55FF55, 92},
+	{"aqua", 'b', 0x55FFFF, 96},
+	{"red", 'c', 0xFF5555, 91},
+	{"light_purple", 'd', 0xFF55FF, 95},
+	{"yellow", 'e', 0xFFFF55, 93},
+	{"white", 'f', 0xFFFFFF, 97},
+}
+
+// colorByName returns the named color corresponding to name, if any.
+func colorByName(name string) (namedColor, bool) {
+	for _, c := range namedColors {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return namedColor{}, false
+}
+
+// colorByCode returns the named color corresponding to a legacy code, if any.
+func colorByCode(code byte) (namedColor, bool) {
+	for _, c := range namedColors {
+		if c.Code == code {
+			return c, true
+		}
+	}
+	return namedColor{}, false
+}
+
+// parseHexColor parses a color of the form #RRGGBB.
+func parseHexColor(color string) (uint32, bool) {
+	if len(color) != 7 || color[0] != '#' {
+		return 0, false
+	}
+
+	rgb, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint32(rgb), true
+}
+
+// nearestColor returns the named color nearest to rgb.
+func nearestColor(rgb uint32) namedColor {
+	var nearest namedColor
+	var nearestDistance int = -1
+
+	for _, c := range namedColors {
+		dr := int(rgb>>16&0xFF) - int(c.RGB>>16&0xFF)
+		dg := int(rgb>>8&0xFF) - int(c.RGB>>8&0xFF)
+		db := int(rgb&0xFF) - int(c.RGB&0xFF)
+		distance := dr*dr + dg*dg + db*db
+
+		if nearestDistance < 0 || distance < nearestDistance {
+			nearest = c
+			nearestDistance = distance
+		}
+	}
+
+	return nearest
+}
+
+// Style is the resolved style of a span of text.
+// Color is either empty (default color), the name of one of the 16 minecraft colors, or a hex color of the form #RRGGBB.
+type Style struct {
+	Color         string `json:"color,omitempty"`
+	Bold          bool   `json:"bold,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Underlined    bool   `json:"underlined,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+	Obfuscated    bool   `json:"obfuscated,omitempty"`
+}
+
+// Span is a piece of text with a single style.
+type Span struct {
+	Text  string `json:"text"`
+	Style Style  `json:"style"`
+}
+
+// RGB returns the color of the style as a 0xRRGGBB value. ok is false if the style has the default color.
+func (s Style) RGB() (rgb uint32, ok bool) {
+	if c, ok := colorByName(s.Color); ok {
+		return c.RGB, true
+	}
+	return parseHexColor(s.Color)
+}
+
+// apply returns the style s, overridden by the style defined in component c.
+// Invalid colors are ignored.
+func (s Style) apply(c Component) Style {
+	color := strings.ToLower(c.Color)
+	if _, ok := colorByName(color); ok {
+		s.Color = color
+	} else if _, ok := parseHexColor(color); ok {
+		s.Color = strings.ToUpper(color)
+	} else if color == "reset" {
+		s.Color = ""
+	}
+
+	if c.Bold != nil {
+		s.Bold = *c.Bold
+	}
+	if c.Italic != nil {
+		s.Italic = *c.Italic
+	}
+	if c.Underlined != nil {
+		s.Underlined = *c.Underlined
+	}
+	if c.Strikethrough != nil {
+		s.Strikethrough = *c.Strikethrough
+	}
+	if c.Obfuscated != nil {
+		s.Obfuscated = *c.Obfuscated
+	}
+
+	return s
+}
+
+// mergeSpans drops empty spans, and merges consecutive spans having the same style.
+func mergeSpans(spans []Span) []Span {
+	merged := make([]Span, 0, len(spans))
+
+	for _, span := range spans {
+		if span.Text == "" {
+			continue
+		}
+
+		if len(merged) > 0 && merged[len(merged)-1].Style == span.Style {
+			merged[len(merged)-1].Text += span.Text
+			continue
+		}
+
+		merged = append(merged, span)
+	}
+
+	return merged
+}
@@ -3,8 +3,8 @@ package networking
 
 import (
 	"bytes"
+	"context"
 	"errors"
-	"fmt"
 	"net"
 	"time"
 )
@@ -19,6 +19,17 @@ var (
 	ErrConnectionAlreadyEstablished error = errors.New("connection has already been established. If you want to reopen a connection for this client, you have to call Disconnect first")
 )
 
+// Resolver resolves the SRV records of hostnames. *net.Resolver implements it.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service string, proto string, name string) (string, []*net.SRV, error)
+}
+
+// Dialer opens connections. *net.Dialer implements it.
+// Custom implementations can for example use a fake in-memory network, or return one end of a net.Pipe.
+type Dialer interface {
+	DialContext(ctx context.Context, network string, address string) (net.Conn, error)
+}
+
 // Conn is common interface between TCP and UDP connections.
 type Conn interface {
 	ExecuteRequest(Output) (Input, error)
@@ -27,51 +38,104 @@ type Conn interface {
 
 // TCPConn is a tcp connection.
 type TCPConn struct {
-	conn *net.TCPConn
+	conn   net.Conn
+	target Target
 }
 
 // DialTCPOptions are the options for the DialTCP function.
 // An empty struct (all fields set to false) is considered as the default behavior for the DialTCP function.
 type DialTCPOptions struct {
-	SkipSRVLookup bool
-	DialTimeout   time.Duration
+	SkipSRVLookup  bool
+	DirectFallback DirectFallback
+	DialTimeout    time.Duration
+	// Resolver is used for the SRV lookup. Defaults to net.DefaultResolver.
+	Resolver Resolver
+	// Dialer is used to open the connection. Defaults to DefaultDialer. The dial is still limited to DialTimeout.
+	Dialer Dialer
+	// ProxyProtocol is the version of the PROXY protocol header sent at the beginning of the connection, if any.
+	ProxyProtocol ProxyProtocolVersion
+	// ProxyProtocolSourceAddr is the source address sent in the PROXY protocol header. Defaults to the local address of the connection.
+	ProxyProtocolSourceAddr *net.TCPAddr
 }
 
 // DialTCP resolve TCP address and connects to the address using TCP.
+// Unless SkipSRVLookup is set, the targets of the minecraft SRV records of hostname are dialed in the order defined by RFC 2782, until one of them answers.
+// DirectFallback controls whether the hostname and port themselves are dialed.
 func DialTCP(hostname string, port int, options DialTCPOptions) (*TCPConn, error) {
-	var _hostname string = hostname
-	var _port int = port
-
-	if !options.SkipSRVLookup {
-		_, addrs, err := net.LookupSRV("minecraft", "tcp", hostname)
-		if err == nil && len(addrs) > 0 {
-			_hostname = addrs[0].Target
-			_port = int(addrs[0].Port)
-		}
+	return DialTCPContext(context.Background(), hostname, port, options)
+}
+
+// DialTCPContext is the same as DialTCP, but the SRV lookup and the dial are aborted as soon as ctx is done.
+func DialTCPContext(ctx context.Context, hostname string, port int, options DialTCPOptions) (*TCPConn, error) {
+	targets, lookupErr, err := resolveTargets(ctx, options.Resolver, hostname, port, "tcp", options.SkipSRVLookup, options.DirectFallback)
+	if err != nil {
+		return nil, err
 	}
 
-	c, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", _hostname, _port), options.DialTimeout)
+	c, target, err := dialTargets(ctx, options.Dialer, options.DialTimeout, "tcp", targets, lookupErr)
 	if err != nil {
 		return nil, err
 	}
 
+	if options.ProxyProtocol != ProxyProtocolNone {
+		err = writeProxyHeader(ctx, c, options.ProxyProtocol, options.ProxyProtocolSourceAddr)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
 	return &TCPConn{
-		conn: c.(*net.TCPConn),
+		conn:   c,
+		target: target,
 	}, nil
 }
 
+// Target returns the target which has been dialed, which differs from the dialed hostname and port if it comes from a SRV record.
+func (tcpc TCPConn) Target() Target {
+	return tcpc.target
+}
+
 // Send sends output to the connection, waits for response and returns the connection input.
 // For TCP connections, as they can be read in multiple time, the connection is simply passed as the reader of the response.
 func (tcpc TCPConn) Send(req Output) (Input, error) {
+	return tcpc.SendContext(context.Background(), req)
+}
+
+// SendContext is the same as Send, but the write, and every read made on the returned input, are interrupted as soon as ctx is done.
+// In this case, the error returned is ctx.Err(), and the connection should be closed as its state is unknown.
+func (tcpc TCPConn) SendContext(ctx context.Context, req Output) (Input, error) {
 	if tcpc.conn == nil {
 		return Input{}, ErrConnectionNotEstablished
 	}
-	_, err := tcpc.conn.Write(req.buf)
+
+	var rw contextReadWriter = contextReadWriter{ctx: ctx, conn: tcpc.conn}
+
+	_, err := rw.Write(req.buf)
 	if err != nil {
 		return Input{}, err
 	}
 
-	return NewInput(tcpc.conn), nil
+	return NewInput(rw), nil
+}
+
+// WriteContext sends output to the connection, without waiting for any response. The write is interrupted as soon as ctx is done.
+// In this case, the error returned is ctx.Err(), and the connection should be closed as the output may have been partially written.
+func (tcpc TCPConn) WriteContext(ctx context.Context, out Output) error {
+	if tcpc.conn == nil {
+		return ErrConnectionNotEstablished
+	}
+
+	var rw contextReadWriter = contextReadWriter{ctx: ctx, conn: tcpc.conn}
+
+	_, err := rw.Write(out.buf)
+	return err
+}
+
+// Input returns the input of the connection, independently of any request.
+// It is useful when responses are read by a single reader, in parallel of the requests being sent. Reads are only interrupted by the read deadline, or by closing the connection.
+func (tcpc TCPConn) Input() Input {
+	return NewInput(tcpc.conn)
 }
 
 // SetReadDeadline sets the read deadline of the underlying connection.
@@ -82,6 +146,30 @@ func (tcpc TCPConn) SetReadDeadline(d time.Duration) error {
 	return tcpc.conn.SetReadDeadline(time.Now().Add(d))
 }
 
+// ClearReadDeadline removes the read deadline of the underlying connection, so that reads never time out.
+func (tcpc TCPConn) ClearReadDeadline() error {
+	if tcpc.conn == nil {
+		return ErrConnectionNotEstablished
+	}
+	return tcpc.conn.SetReadDeadline(time.Time{})
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection.
+func (tcpc TCPConn) SetWriteDeadline(d time.Duration) error {
+	if tcpc.conn == nil {
+		return ErrConnectionNotEstablished
+	}
+	return tcpc.conn.SetWriteDeadline(time.Now().Add(d))
+}
+
+// ClearWriteDeadline removes the write deadline of the underlying connection, so that writes never time out.
+func (tcpc TCPConn) ClearWriteDeadline() error {
+	if tcpc.conn == nil {
+		return ErrConnectionNotEstablished
+	}
+	return tcpc.conn.SetWriteDeadline(time.Time{})
+}
+
 // Close closes the connection.
 func (tcpc TCPConn) Close() error {
 	if tcpc.conn == nil {
@@ -92,7 +180,8 @@ func (tcpc TCPConn) Close() error {
 
 // UDPConn is a udp connection.
 type UDPConn struct {
-	conn *net.UDPConn
+	conn   net.Conn
+	target Target
 }
 
 // DialUDPOptions are the options for the DialUDP function.
@@ -100,51 +189,73 @@ type UDPConn struct {
 type DialUDPOptions struct {
 	SkipSRVLookup                bool
 	ForceUDPProtocolForSRVLookup bool
+	DirectFallback               DirectFallback
 	DialTimeout                  time.Duration
+	// Resolver is used for the SRV lookup. Defaults to net.DefaultResolver.
+	Resolver Resolver
+	// Dialer is used to open the connection. Defaults to DefaultDialer. The dial is still limited to DialTimeout.
+	// The returned connection must preserve datagram boundaries : each read returns a single datagram.
+	Dialer Dialer
 }
 
 // DialUDP resolve UDP address and connects to the address using UDP.
+// SRV records are handled as in DialTCP. As UDP dials don't reach the server, only targets which can't be resolved are skipped.
 func DialUDP(hostname string, port int, options DialUDPOptions) (*UDPConn, error) {
-	var _hostname string = hostname
-	var _port int = port
+	return DialUDPContext(context.Background(), hostname, port, options)
+}
+
+// DialUDPContext is the same as DialUDP, but the SRV lookup and the dial are aborted as soon as ctx is done.
+func DialUDPContext(ctx context.Context, hostname string, port int, options DialUDPOptions) (*UDPConn, error) {
 	var protocol string = "tcp"
 
 	if options.ForceUDPProtocolForSRVLookup {
 		protocol = "udp"
 	}
 
-	if !options.SkipSRVLookup {
-		_, addrs, err := net.LookupSRV("minecraft", protocol, hostname)
-		if err == nil && len(addrs) > 0 {
-			_hostname = addrs[0].Target
-			_port = int(addrs[0].Port)
-		}
+	targets, lookupErr, err := resolveTargets(ctx, options.Resolver, hostname, port, protocol, options.SkipSRVLookup, options.DirectFallback)
+	if err != nil {
+		return nil, err
 	}
 
-	c, err := net.DialTimeout("udp", fmt.Sprintf("%s:%d", _hostname, _port), options.DialTimeout)
+	c, target, err := dialTargets(ctx, options.Dialer, options.DialTimeout, "udp", targets, lookupErr)
 	if err != nil {
 		return nil, err
 	}
 
 	return &UDPConn{
-		conn: c.(*net.UDPConn),
+		conn:   c,
+		target: target,
 	}, nil
 }
 
+// Target returns the target which has been dialed, which differs from the dialed hostname and port if it comes from a SRV record.
+func (udpc UDPConn) Target() Target {
+	return udpc.target
+}
+
 // Send sends output to the connection, waits for response and returns the connection input.
 // For UDP connections, as they cannot be read in multiple time, the connection is read a single time and loaded into a buffer of size MaximumUDPDatagramLength.
 // UDP datagram length should not be over MaximumUDPDatagramLength, so the entire datagram should be loaded. A *bytes.Buffer is the passed as the reader for the response.
 func (udpc UDPConn) Send(out Output) (Input, error) {
+	return udpc.SendContext(context.Background(), out)
+}
+
+// SendContext is the same as Send, but the write and the read are interrupted as soon as ctx is done.
+// In this case, the error returned is ctx.Err().
+func (udpc UDPConn) SendContext(ctx context.Context, out Output) (Input, error) {
 	if udpc.conn == nil {
 		return Input{}, ErrConnectionNotEstablished
 	}
-	_, err := udpc.conn.Write(out.buf)
+
+	var rw contextReadWriter = contextReadWriter{ctx: ctx, conn: udpc.conn}
+
+	_, err := rw.Write(out.buf)
 	if err != nil {
 		return Input{}, err
 	}
 
 	var buf [MaximumUDPDatagramLength]byte
-	n, err := udpc.conn.Read(buf[:])
+	n, err := rw.Read(buf[:])
 	if err != nil {
 		return Input{}, err
 	}
@@ -160,6 +271,14 @@ func (udpc UDPConn) SetReadDeadline(d time.Duration) error {
 	return udpc.conn.SetReadDeadline(time.Now().Add(d))
 }
 
+// RemoteAddr returns the address of the remote end of the connection.
+func (udpc UDPConn) RemoteAddr() net.Addr {
+	if udpc.conn == nil {
+		return nil
+	}
+	return udpc.conn.RemoteAddr()
+}
+
 // Close closes the connection.
 func (udpc UDPConn) Close() error {
 	if udpc.conn == nil {
@@ -167,3 +286,73 @@ func (udpc UDPConn) Close() error {
 	}
 	return udpc.conn.Close()
 }
+
+// aLongTimeAgo is a non-zero time, far in the past, used to interrupt pending I/O on a connection.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// contextReadWriter wraps a connection so that each read and write is interrupted as soon as ctx is done.
+type contextReadWriter struct {
+	ctx  context.Context
+	conn net.Conn
+}
+
+// Read reads from the underlying connection, unless ctx is done.
+func (crw contextReadWriter) Read(buf []byte) (int, error) {
+	if err := crw.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	stop := watchContext(crw.ctx, crw.conn)
+	n, err := crw.conn.Read(buf)
+	stop()
+
+	return n, contextError(crw.ctx, err)
+}
+
+// Write writes to the underlying connection, unless ctx is done.
+func (crw contextReadWriter) Write(buf []byte) (int, error) {
+	if err := crw.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	stop := watchContext(crw.ctx, crw.conn)
+	n, err := crw.conn.Write(buf)
+	stop()
+
+	return n, contextError(crw.ctx, err)
+}
+
+// watchContext interrupts pending I/O on conn as soon as ctx is done, until the returned function is called.
+func watchContext(ctx context.Context, conn net.Conn) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(aLongTimeAgo)
+		case <-stop:
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// contextError returns ctx.Err() in place of err if ctx is done, as I/O errors are then caused by the interruption.
+func contextError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
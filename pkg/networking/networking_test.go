@@ -0,0 +1,188 @@
+package networking
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// silentTCPListener returns a listener whose connections are accepted but never answered, and its port.
+func silentTCPListener(t *testing.T) (net.Listener, int) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	return l, l.Addr().(*net.TCPAddr).Port
+}
+
+func TestTCPSendContextCancel(t *testing.T) {
+	l, port := silentTCPListener(t)
+	defer l.Close()
+
+	conn, err := DialTCPContext(context.Background(), "127.0.0.1", port, DialTCPOptions{SkipSRVLookup: true})
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	out := NewOutput()
+	out.WriteByte(0)
+
+	in, err := conn.SendContext(ctx, out)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	_, err = in.ReadByte()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v got %v.", context.Canceled, err)
+	}
+}
+
+func TestTCPSendContextDeadline(t *testing.T) {
+	l, port := silentTCPListener(t)
+	defer l.Close()
+
+	conn, err := DialTCP("127.0.0.1", port, DialTCPOptions{SkipSRVLookup: true})
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	in, err := conn.SendContext(ctx, NewOutput())
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	_, err = in.ReadBytes(4)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v got %v.", context.DeadlineExceeded, err)
+	}
+}
+
+func TestUDPSendContextCancel(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer pc.Close()
+
+	port := pc.LocalAddr().(*net.UDPAddr).Port
+
+	conn, err := DialUDPContext(context.Background(), "127.0.0.1", port, DialUDPOptions{SkipSRVLookup: true})
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	out := NewOutput()
+	out.WriteByte(0)
+
+	_, err = conn.SendContext(ctx, out)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v got %v.", context.Canceled, err)
+	}
+}
+
+func TestDialTCPContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := DialTCPContext(ctx, "127.0.0.1", 1, DialTCPOptions{SkipSRVLookup: true})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v got %v.", context.Canceled, err)
+	}
+}
+
+// pipeDialer is a Dialer connecting to an in-memory echo server through net.Pipe, and recording the dialed addresses.
+type pipeDialer struct {
+	addresses chan string
+}
+
+func (d pipeDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.addresses <- address
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		var buf [16]byte
+		n, err := server.Read(buf[:])
+		if err != nil {
+			return
+		}
+		server.Write(buf[:n])
+	}()
+
+	return client, nil
+}
+
+// blockingDialer is a Dialer which never connects, until ctx is done.
+type blockingDialer struct{}
+
+func (blockingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestDialCustomDialer(t *testing.T) {
+	dialer := pipeDialer{addresses: make(chan string, 1)}
+
+	conn, err := DialTCP("mc.example.com", 25565, DialTCPOptions{
+		Resolver: fakeResolver{records: []*net.SRV{{Target: "backend.example.com.", Port: 25566}}},
+		Dialer:   dialer,
+	})
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+
+	if address := <-dialer.addresses; address != "backend.example.com:25566" {
+		t.Errorf("Expected backend.example.com:25566 got %s.", address)
+	}
+
+	out := NewOutput()
+	out.WriteByte(42)
+
+	in, err := conn.Send(out)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	b, err := in.ReadByte()
+	if err != nil || b != 42 {
+		t.Errorf("Expected 42, <nil> got %d, %v.", b, err)
+	}
+}
+
+func TestDialCustomDialerTimeout(t *testing.T) {
+	_, err := DialUDP("127.0.0.1", 1, DialUDPOptions{
+		SkipSRVLookup: true,
+		DialTimeout:   50 * time.Millisecond,
+		Dialer:        blockingDialer{},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v got %v.", context.DeadlineExceeded, err)
+	}
+}
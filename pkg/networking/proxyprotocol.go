@@ -0,0 +1,282 @@
+package networking
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ProxyProtocolVersion is a version of the HAProxy PROXY protocol.
+type ProxyProtocolVersion int
+
+const (
+	ProxyProtocolNone ProxyProtocolVersion = iota
+	ProxyProtocolV1                        // human-readable header
+	ProxyProtocolV2                        // binary header
+
+	MaximumProxyHeaderV1Length int = 107
+)
+
+var (
+	ErrInvalidProxyHeader error = errors.New("invalid PROXY protocol header")
+
+	// proxyHeaderV2Signature is the signature starting every PROXY protocol v2 header.
+	proxyHeaderV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// ProxyHeader is a PROXY protocol header, sent by proxies at the beginning of connections to tell the addresses of the original connection.
+// Nil addresses mean the original connection is unknown, e.g. for health checks made by the proxy itself.
+type ProxyHeader struct {
+	Version         ProxyProtocolVersion
+	SourceAddr      *net.TCPAddr
+	DestinationAddr *net.TCPAddr
+}
+
+// Bytes returns the header, encoded as defined by its version.
+// If any of the addresses is unknown, they are both left out (UNKNOWN in v1, LOCAL command in v2).
+func (h ProxyHeader) Bytes() []byte {
+	known := h.SourceAddr != nil && h.DestinationAddr != nil
+	ipv4 := known && h.SourceAddr.IP.To4() != nil && h.DestinationAddr.IP.To4() != nil
+
+	var buf bytes.Buffer
+	if h.Version == ProxyProtocolV1 {
+		switch {
+		case !known:
+			buf.WriteString("PROXY UNKNOWN\r\n")
+		case ipv4:
+			fmt.Fprintf(&buf, "PROXY TCP4 %s %s %d %d\r\n", h.SourceAddr.IP.To4(), h.DestinationAddr.IP.To4(), h.SourceAddr.Port, h.DestinationAddr.Port)
+		default:
+			fmt.Fprintf(&buf, "PROXY TCP6 %s %s %d %d\r\n", formatIPv6(h.SourceAddr.IP), formatIPv6(h.DestinationAddr.IP), h.SourceAddr.Port, h.DestinationAddr.Port)
+		}
+		return buf.Bytes()
+	}
+
+	buf.Write(proxyHeaderV2Signature)
+	switch {
+	case !known:
+		buf.Write([]byte{0x20, 0x00, 0x00, 0x00}) // LOCAL, UNSPEC, no address
+	case ipv4:
+		buf.Write([]byte{0x21, 0x11, 0x00, 12}) // PROXY, TCP over IPv4
+		buf.Write(h.SourceAddr.IP.To4())
+		buf.Write(h.DestinationAddr.IP.To4())
+	default:
+		buf.Write([]byte{0x21, 0x21, 0x00, 36}) // PROXY, TCP over IPv6
+		buf.Write(h.SourceAddr.IP.To16())
+		buf.Write(h.DestinationAddr.IP.To16())
+	}
+	if known {
+		var ports [4]byte
+		binary.BigEndian.PutUint16(ports[:2], uint16(h.SourceAddr.Port))
+		binary.BigEndian.PutUint16(ports[2:], uint16(h.DestinationAddr.Port))
+		buf.Write(ports[:])
+	}
+
+	return buf.Bytes()
+}
+
+// formatIPv6 formats ip in the IPv6 textual form, even if it is an IPv4 address (which is then IPv4-mapped).
+func formatIPv6(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return "::ffff:" + ip4.String()
+	}
+	return ip.String()
+}
+
+// ReadProxyHeader reads a PROXY protocol header (v1 or v2, detected from its first bytes) from r.
+// In v2 headers, TLVs are skipped, and addresses of other families than TCP/UDP over IPv4/IPv6 are considered unknown.
+func ReadProxyHeader(r *bufio.Reader) (ProxyHeader, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return ProxyHeader{}, err
+	}
+
+	switch first[0] {
+	case 'P':
+		return readProxyHeaderV1(r)
+	case proxyHeaderV2Signature[0]:
+		return readProxyHeaderV2(r)
+	}
+
+	return ProxyHeader{}, ErrInvalidProxyHeader
+}
+
+// readProxyHeaderV1 reads a PROXY protocol v1 header.
+func readProxyHeaderV1(r *bufio.Reader) (ProxyHeader, error) {
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull || len(line) > MaximumProxyHeaderV1Length {
+		return ProxyHeader{}, ErrInvalidProxyHeader
+	}
+	if err != nil {
+		return ProxyHeader{}, err
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return ProxyHeader{}, ErrInvalidProxyHeader
+	}
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+
+	res := ProxyHeader{Version: ProxyProtocolV1}
+	if len(fields) >= 2 && fields[0] == "PROXY" && fields[1] == "UNKNOWN" {
+		return res, nil
+	}
+	if len(fields) != 6 || fields[0] != "PROXY" || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return ProxyHeader{}, ErrInvalidProxyHeader
+	}
+
+	res.SourceAddr, err = parseProxyHeaderV1Address(fields[2], fields[4], fields[1] == "TCP4")
+	if err != nil {
+		return ProxyHeader{}, err
+	}
+	res.DestinationAddr, err = parseProxyHeaderV1Address(fields[3], fields[5], fields[1] == "TCP4")
+	if err != nil {
+		return ProxyHeader{}, err
+	}
+
+	return res, nil
+}
+
+// parseProxyHeaderV1Address parses an address of a PROXY protocol v1 header, whose IP must be of the family of the header.
+func parseProxyHeaderV1Address(ip string, port string, ipv4 bool) (*net.TCPAddr, error) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil || !strings.Contains(ip, ":") != ipv4 {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	parsedPort, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	return &net.TCPAddr{IP: parsedIP, Port: int(parsedPort)}, nil
+}
+
+// readProxyHeaderV2 reads a PROXY protocol v2 header.
+func readProxyHeaderV2(r *bufio.Reader) (ProxyHeader, error) {
+	var header [16]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return ProxyHeader{}, err
+	}
+	if !bytes.Equal(header[:12], proxyHeaderV2Signature) || header[12]>>4 != 2 {
+		return ProxyHeader{}, ErrInvalidProxyHeader
+	}
+
+	content := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	_, err = io.ReadFull(r, content)
+	if err != nil {
+		return ProxyHeader{}, err
+	}
+
+	res := ProxyHeader{Version: ProxyProtocolV2}
+	switch header[12] & 0x0F {
+	case 0x00: // LOCAL
+		return res, nil
+	case 0x01: // PROXY
+	default:
+		return ProxyHeader{}, ErrInvalidProxyHeader
+	}
+
+	var ipLength int
+	switch header[13] >> 4 {
+	case 0x01:
+		ipLength = net.IPv4len
+	case 0x02:
+		ipLength = net.IPv6len
+	default:
+		return res, nil
+	}
+	if len(content) < 2*ipLength+4 {
+		return ProxyHeader{}, ErrInvalidProxyHeader
+	}
+
+	res.SourceAddr = &net.TCPAddr{
+		IP:   net.IP(content[:ipLength]),
+		Port: int(binary.BigEndian.Uint16(content[2*ipLength:])),
+	}
+	res.DestinationAddr = &net.TCPAddr{
+		IP:   net.IP(content[ipLength : 2*ipLength]),
+		Port: int(binary.BigEndian.Uint16(content[2*ipLength+2:])),
+	}
+
+	return res, nil
+}
+
+// writeProxyHeader writes a PROXY protocol header at the beginning of conn. The destination address is the remote address of conn.
+// If sourceAddr is nil, the local address of conn is used. Addresses which aren't TCP ones are sent as unknown.
+func writeProxyHeader(ctx context.Context, conn net.Conn, version ProxyProtocolVersion, sourceAddr *net.TCPAddr) error {
+	header := ProxyHeader{Version: version, SourceAddr: sourceAddr}
+	if header.SourceAddr == nil {
+		header.SourceAddr, _ = conn.LocalAddr().(*net.TCPAddr)
+	}
+	header.DestinationAddr, _ = conn.RemoteAddr().(*net.TCPAddr)
+
+	var rw contextReadWriter = contextReadWriter{ctx: ctx, conn: conn}
+
+	_, err := rw.Write(header.Bytes())
+	return err
+}
+
+// NewProxyProtocolListener returns a listener whose connections must start with a PROXY protocol header (v1 or v2).
+// The header is read on the first Read or RemoteAddr call, and RemoteAddr then returns the source address it contains, if known.
+// Reads on connections without a valid header fail with ErrInvalidProxyHeader.
+func NewProxyProtocolListener(l net.Listener) net.Listener {
+	return proxyProtocolListener{Listener: l}
+}
+
+// proxyProtocolListener is a listener whose connections start with a PROXY protocol header.
+type proxyProtocolListener struct {
+	net.Listener
+}
+
+// Accept waits for the next connection. Its header isn't read yet.
+func (l proxyProtocolListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &proxyProtocolConn{Conn: c, r: bufio.NewReader(c)}, nil
+}
+
+// proxyProtocolConn is a connection starting with a PROXY protocol header.
+type proxyProtocolConn struct {
+	net.Conn
+	r *bufio.Reader
+
+	once   sync.Once
+	header ProxyHeader
+	err    error
+}
+
+// readHeader reads the header, if it hasn't been read yet.
+func (c *proxyProtocolConn) readHeader() error {
+	c.once.Do(func() {
+		c.header, c.err = ReadProxyHeader(c.r)
+	})
+	return c.err
+}
+
+// Read reads from the connection, after the header.
+func (c *proxyProtocolConn) Read(b []byte) (int, error) {
+	err := c.readHeader()
+	if err != nil {
+		return 0, err
+	}
+	return c.r.Read(b)
+}
+
+// RemoteAddr returns the source address of the header, or the address of the proxy if it is unknown.
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	if c.readHeader() == nil && c.header.SourceAddr != nil {
+		return c.header.SourceAddr
+	}
+	return c.Conn.RemoteAddr()
+}
@@ -0,0 +1,111 @@
+package networking
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestProxyHeader(t *testing.T) {
+	source4 := &net.TCPAddr{IP: net.IPv4(203, 0, 113, 7).To4(), Port: 51000}
+	destination4 := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2).To4(), Port: 25565}
+	source6 := &net.TCPAddr{IP: net.ParseIP("2001:db8::7"), Port: 51000}
+
+	inputs := []ProxyHeader{
+		{Version: ProxyProtocolV1, SourceAddr: source4, DestinationAddr: destination4},
+		{Version: ProxyProtocolV1, SourceAddr: source6, DestinationAddr: destination4},
+		{Version: ProxyProtocolV1},
+		{Version: ProxyProtocolV2, SourceAddr: source4, DestinationAddr: destination4},
+		{Version: ProxyProtocolV2, SourceAddr: source6, DestinationAddr: destination4},
+		{Version: ProxyProtocolV2},
+	}
+	expectedLengths := []int{45, 52, 15, 28, 52, 16}
+
+	for i := 0; i < len(inputs); i++ {
+		raw := inputs[i].Bytes()
+		if len(raw) != expectedLengths[i] {
+			t.Errorf("Value %d: Expected %d bytes got %d bytes (%q).", i, expectedLengths[i], len(raw), raw)
+		}
+
+		res, err := ReadProxyHeader(bufio.NewReader(bytes.NewReader(raw)))
+		if err != nil {
+			t.Errorf("Value %d: Expected <nil> got %v.", i, err)
+			continue
+		}
+
+		if res.Version != inputs[i].Version || res.SourceAddr.String() != inputs[i].SourceAddr.String() {
+			t.Errorf("Value %d: Expected %v got %v.", i, inputs[i], res)
+		}
+		// IPv4 destinations are IPv4-mapped in IPv6 headers.
+		if inputs[i].DestinationAddr != nil && !res.DestinationAddr.IP.Equal(inputs[i].DestinationAddr.IP) {
+			t.Errorf("Value %d: Expected %v got %v.", i, inputs[i].DestinationAddr, res.DestinationAddr)
+		}
+	}
+}
+
+func TestReadProxyHeaderInvalid(t *testing.T) {
+	inputs := []string{
+		"\x10\x00",
+		"PROXY TCP4 1.2.3.4 5.6.7.8 1 2\n",
+		"PROXY TCP4 ::1 5.6.7.8 1 2\r\n",
+		"PROXY TCP6 1.2.3.4 5.6.7.8 1 2\r\n",
+		"PROXY TCP4 1.2.3.4 5.6.7.8 1 99999\r\n",
+		"\r\n\r\n\x00\r\nQUIT\n\x11\x11\x00\x00",
+		"\r\n\r\n\x00\r\nQUIT\n\x21\x11\x00\x04\x00\x00\x00\x00",
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		_, err := ReadProxyHeader(bufio.NewReader(bytes.NewReader([]byte(inputs[i]))))
+		if err != ErrInvalidProxyHeader {
+			t.Errorf("Value %d: Expected %v got %v.", i, ErrInvalidProxyHeader, err)
+		}
+	}
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	l = NewProxyProtocolListener(l)
+	defer l.Close()
+
+	source := &net.TCPAddr{IP: net.IPv4(198, 51, 100, 1).To4(), Port: 40000}
+	inputs := []ProxyProtocolVersion{ProxyProtocolV1, ProxyProtocolV2}
+
+	for i := 0; i < len(inputs); i++ {
+		conn, err := DialTCP("127.0.0.1", l.Addr().(*net.TCPAddr).Port, DialTCPOptions{
+			SkipSRVLookup:           true,
+			ProxyProtocol:           inputs[i],
+			ProxyProtocolSourceAddr: source,
+		})
+		if err != nil {
+			t.Fatalf("Value %d: Expected <nil> got %v.", i, err)
+		}
+
+		out := NewOutput()
+		out.WriteByte(42)
+		_, err = conn.Send(out)
+		if err != nil {
+			t.Fatalf("Value %d: Expected <nil> got %v.", i, err)
+		}
+
+		accepted, err := l.Accept()
+		if err != nil {
+			t.Fatalf("Value %d: Expected <nil> got %v.", i, err)
+		}
+
+		var buf [1]byte
+		_, err = accepted.Read(buf[:])
+		if err != nil || buf[0] != 42 {
+			t.Errorf("Value %d: Expected 42, <nil> got %d, %v.", i, buf[0], err)
+		}
+		if accepted.RemoteAddr().String() != source.String() {
+			t.Errorf("Value %d: Expected %v got %v.", i, source, accepted.RemoteAddr())
+		}
+
+		accepted.Close()
+		conn.Close()
+	}
+}
@@ -0,0 +1,124 @@
+package networking
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// RetryPolicy controls the retransmission of requests sent over UDP, when no valid response is received in time.
+type RetryPolicy struct {
+	// Attempts is the maximum number of times a request is sent. Values below 1 are considered as 1.
+	Attempts int
+	// Timeout is the time waited for a valid response to the first attempt. If 0, the whole read timeout is waited.
+	Timeout time.Duration
+	// Backoff multiplies the timeout after each attempt (exponential backoff). Values below 1 are considered as 1.
+	Backoff float64
+}
+
+// DefaultRetryPolicy is the retry policy of UDP clients : 3 attempts, waiting 1s, then 2s, then the rest of the read timeout.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts: 3,
+	Timeout:  time.Second,
+	Backoff:  2,
+}
+
+// RetryError is returned when no valid response has been received after all attempts.
+type RetryError struct {
+	Attempts int
+	// Discarded is the number of invalid datagrams received, and ignored.
+	Discarded int
+	// Err is the error of the last attempt, usually a timeout.
+	Err error
+}
+
+// Error returns the error of the last attempt, with the number of attempts.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("no valid response after %d attempt(s), %d datagram(s) discarded: %v", e.Attempts, e.Discarded, e.Err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// ExchangeContext sends the request, and waits for a response accepted by handle : datagrams for which handle returns an error are discarded.
+// request is called before each attempt, so that the datagram sent can change (e.g. to include a timestamp).
+// If no response is accepted within the attempt timeout, the request is sent again, as defined by policy. The whole exchange is limited to readTimeout, if not 0.
+// It returns the number of attempts made. If no response is accepted in time, the error is a *RetryError.
+// As in SendContext, the exchange is interrupted as soon as ctx is done, and the error returned is then ctx.Err().
+func (udpc UDPConn) ExchangeContext(ctx context.Context, request func() Output, readTimeout time.Duration, policy RetryPolicy, handle func(Input) error) (int, error) {
+	if udpc.conn == nil {
+		return 0, ErrConnectionNotEstablished
+	}
+
+	attempts := policy.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := policy.Backoff
+	if backoff < 1 {
+		backoff = 1
+	}
+
+	var deadline time.Time
+	if readTimeout > 0 {
+		deadline = time.Now().Add(readTimeout)
+	}
+
+	var rw contextReadWriter = contextReadWriter{ctx: ctx, conn: udpc.conn}
+	var buf [MaximumUDPDatagramLength]byte
+	discarded := 0
+	timeout := policy.Timeout
+
+	for attempt := 1; ; attempt++ {
+		_, err := rw.Write(request().buf)
+		if err != nil {
+			return attempt, err
+		}
+
+		// The last attempt waits until the end of the exchange.
+		attemptDeadline := deadline
+		if attempt < attempts && timeout > 0 {
+			if d := time.Now().Add(timeout); deadline.IsZero() || d.Before(deadline) {
+				attemptDeadline = d
+			}
+		}
+
+		err = udpc.conn.SetReadDeadline(attemptDeadline)
+		if err != nil {
+			return attempt, err
+		}
+
+		for {
+			var n int
+			n, err = rw.Read(buf[:])
+			if err != nil {
+				break
+			}
+
+			if handle(NewInput(bytes.NewReader(buf[:n]))) == nil {
+				return attempt, nil
+			}
+			discarded++
+		}
+
+		// context.DeadlineExceeded is a timeout too, but it ends the exchange.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return attempt, ctxErr
+		}
+
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			return attempt, err
+		}
+		if attempt >= attempts || (!deadline.IsZero() && !time.Now().Before(deadline)) {
+			return attempt, &RetryError{Attempts: attempt, Discarded: discarded, Err: err}
+		}
+
+		timeout = time.Duration(float64(timeout) * backoff)
+	}
+}
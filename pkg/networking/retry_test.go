@@ -0,0 +1,123 @@
+package networking
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// startTestLossyServer starts an UDP server which ignores the first drop datagrams, and answers the next ones with "invalid", then with the datagram itself.
+// It returns its port.
+func startTestLossyServer(t *testing.T, drop int) int {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	t.Cleanup(func() {
+		pc.Close()
+	})
+
+	go func() {
+		var buf [MaximumUDPDatagramLength]byte
+		for received := 1; ; received++ {
+			n, addr, err := pc.ReadFrom(buf[:])
+			if err != nil {
+				return
+			}
+			if received <= drop {
+				continue
+			}
+			pc.WriteTo([]byte("invalid"), addr)
+			pc.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	return pc.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestUDPExchangeContext(t *testing.T) {
+	inputs := []struct {
+		drop        int
+		policy      RetryPolicy
+		readTimeout time.Duration
+	}{
+		{0, RetryPolicy{Attempts: 3, Timeout: 50 * time.Millisecond, Backoff: 2}, time.Second},
+		{2, RetryPolicy{Attempts: 3, Timeout: 50 * time.Millisecond, Backoff: 2}, time.Second},
+		{3, RetryPolicy{Attempts: 3, Timeout: 50 * time.Millisecond, Backoff: 2}, 300 * time.Millisecond},
+		{1, RetryPolicy{Attempts: 3, Timeout: 200 * time.Millisecond, Backoff: 1}, 100 * time.Millisecond},
+	}
+	expectedAttempts := []int{1, 3, 3, 1}
+	expectedErrors := []bool{false, false, true, true}
+
+	for i := 0; i < len(inputs); i++ {
+		port := startTestLossyServer(t, inputs[i].drop)
+
+		conn, err := DialUDP("127.0.0.1", port, DialUDPOptions{SkipSRVLookup: true})
+		if err != nil {
+			t.Fatalf("Value %d: Expected <nil> got %v.", i, err)
+		}
+		defer conn.Close()
+
+		out := NewOutput()
+		out.WriteBytes([]byte("hello"))
+
+		discarded := 0
+		attempts, err := conn.ExchangeContext(context.Background(), func() Output {
+			return out
+		}, inputs[i].readTimeout, inputs[i].policy, func(in Input) error {
+			res, err := in.ReadBytes(5)
+			if err != nil || !bytes.Equal(res, []byte("hello")) {
+				discarded++
+				return errors.New("unexpected response")
+			}
+			return nil
+		})
+
+		if attempts != expectedAttempts[i] {
+			t.Errorf("Value %d: Expected %d attempts got %d.", i, expectedAttempts[i], attempts)
+		}
+
+		var retryErr *RetryError
+		if errors.As(err, &retryErr) != expectedErrors[i] {
+			t.Errorf("Value %d: Expected *RetryError %v got %v.", i, expectedErrors[i], err)
+			continue
+		}
+		if retryErr != nil && retryErr.Attempts != attempts {
+			t.Errorf("Value %d: Expected %d got %d.", i, attempts, retryErr.Attempts)
+		}
+		if !expectedErrors[i] && discarded != 1 {
+			t.Errorf("Value %d: Expected 1 discarded datagram got %d.", i, discarded)
+		}
+	}
+}
+
+func TestUDPExchangeContextDeadline(t *testing.T) {
+	port := startTestLossyServer(t, 10)
+
+	conn, err := DialUDP("127.0.0.1", port, DialUDPOptions{SkipSRVLookup: true})
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	out := NewOutput()
+	out.WriteBytes([]byte("hello"))
+
+	// The context expires during the last attempt : its error is returned as is, not as a *RetryError.
+	_, err = conn.ExchangeContext(ctx, func() Output {
+		return out
+	}, time.Second, RetryPolicy{Attempts: 2, Timeout: 60 * time.Millisecond, Backoff: 1}, func(in Input) error {
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected %v got %v.", context.DeadlineExceeded, err)
+	}
+}
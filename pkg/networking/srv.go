@@ -0,0 +1,193 @@
+package networking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DirectFallback controls when the hostname and port themselves are dialed, instead of (or after) the targets of the SRV records.
+type DirectFallback int
+
+const (
+	// FallbackWithoutSRVRecord dials the hostname and port when no SRV record is found. It is the default behavior.
+	FallbackWithoutSRVRecord DirectFallback = iota
+	// FallbackNever only dials the targets of the SRV records.
+	FallbackNever
+	// FallbackAlways also dials the hostname and port when every target of the SRV records failed.
+	FallbackAlways
+)
+
+var (
+	ErrNoSRVRecord           error = errors.New("no SRV record found")
+	ErrSRVServiceUnavailable error = errors.New("service is decidedly not available at this domain (SRV target \".\")")
+)
+
+// Target is an address which has been dialed.
+type Target struct {
+	Hostname string `json:"hostname"`
+	Port     int    `json:"port"`
+	// SRV tells whether the target comes from a SRV record.
+	SRV bool `json:"srv"`
+}
+
+// String returns the target as a "host:port" address.
+func (target Target) String() string {
+	return net.JoinHostPort(target.Hostname, strconv.Itoa(target.Port))
+}
+
+// OrderSRV orders SRV records as defined by RFC 2782 : by ascending priority, then, within a priority, by weighted random selection.
+// Records of weight 0 have a very small chance to be selected before the others of the same priority.
+func OrderSRV(records []*net.SRV) []*net.SRV {
+	return orderSRV(records, rand.Intn)
+}
+
+// orderSRV is the same as OrderSRV, with a custom random source : intn(n) returns a number in [0, n).
+func orderSRV(records []*net.SRV, intn func(int) int) []*net.SRV {
+	sorted := make([]*net.SRV, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	res := make([]*net.SRV, 0, len(sorted))
+	for start := 0; start < len(sorted); {
+		end := start
+		for end < len(sorted) && sorted[end].Priority == sorted[start].Priority {
+			end++
+		}
+
+		// Records of weight 0 are placed first, so that they are only selected when the random number is 0.
+		group := make([]*net.SRV, 0, end-start)
+		for _, record := range sorted[start:end] {
+			if record.Weight == 0 {
+				group = append(group, record)
+			}
+		}
+		for _, record := range sorted[start:end] {
+			if record.Weight != 0 {
+				group = append(group, record)
+			}
+		}
+
+		for len(group) > 0 {
+			sum := 0
+			for _, record := range group {
+				sum += int(record.Weight)
+			}
+
+			n := intn(sum + 1)
+			i, running := 0, int(group[0].Weight)
+			for running < n {
+				i++
+				running += int(group[i].Weight)
+			}
+
+			res = append(res, group[i])
+			group = append(group[:i], group[i+1:]...)
+		}
+
+		start = end
+	}
+
+	return res
+}
+
+// resolveTargets returns the targets to dial, in order, for the minecraft service of hostname.
+// proto is the protocol of the SRV record ("tcp" or "udp").
+// If the lookup failed for another reason than the absence of record, and the hostname is dialed instead, the lookup error is returned as lookupErr.
+func resolveTargets(ctx context.Context, resolver Resolver, hostname string, port int, proto string, skipSRVLookup bool, fallback DirectFallback) (targets []Target, lookupErr error, err error) {
+	direct := Target{Hostname: hostname, Port: port}
+	if skipSRVLookup {
+		return []Target{direct}, nil, nil
+	}
+
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	_, records, err := resolver.LookupSRV(ctx, "minecraft", proto, hostname)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			records, err = nil, nil
+		} else if fallback == FallbackNever {
+			return nil, nil, err
+		} else {
+			return []Target{direct}, err, nil
+		}
+	}
+
+	if len(records) == 1 && records[0].Target == "." {
+		return nil, nil, ErrSRVServiceUnavailable
+	}
+	if len(records) == 0 {
+		if fallback == FallbackNever {
+			return nil, nil, ErrNoSRVRecord
+		}
+		return []Target{direct}, nil, nil
+	}
+
+	for _, record := range OrderSRV(records) {
+		targets = append(targets, Target{
+			Hostname: strings.TrimSuffix(record.Target, "."),
+			Port:     int(record.Port),
+			SRV:      true,
+		})
+	}
+	if fallback == FallbackAlways {
+		targets = append(targets, direct)
+	}
+
+	return targets, nil, nil
+}
+
+// dialTargets dials each target in order, until one of them succeeds, and returns the connection with the target which answered.
+// Each dial is limited to dialTimeout, if not 0. If every target fails, the error of the last one is returned, along with lookupErr if any.
+func dialTargets(ctx context.Context, dialer Dialer, dialTimeout time.Duration, network string, targets []Target, lookupErr error) (net.Conn, Target, error) {
+	if dialer == nil {
+		dialer = DefaultDialer
+	}
+
+	var err error
+	for i, target := range targets {
+		var c net.Conn
+		c, err = dialContext(ctx, dialer, dialTimeout, network, target.String())
+		if err == nil {
+			return c, target, nil
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, Target{}, err
+		}
+		if len(targets) > 1 {
+			err = fmt.Errorf("target %d/%d (%s): %w", i+1, len(targets), target, err)
+		}
+	}
+
+	if lookupErr != nil {
+		err = fmt.Errorf("%w (SRV lookup: %v)", err, lookupErr)
+	}
+	return nil, Target{}, err
+}
+
+// dialContext dials address with dialer, within dialTimeout if not 0.
+func dialContext(ctx context.Context, dialer Dialer, dialTimeout time.Duration, network string, address string) (net.Conn, error) {
+	if dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dialTimeout)
+		defer cancel()
+	}
+
+	return dialer.DialContext(ctx, network, address)
+}
@@ -0,0 +1,144 @@
+package networking
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestOrderSRV(t *testing.T) {
+	records := []*net.SRV{
+		{Target: "c", Priority: 20, Weight: 10},
+		{Target: "a1", Priority: 10, Weight: 60},
+		{Target: "a2", Priority: 10, Weight: 0},
+		{Target: "a3", Priority: 10, Weight: 40},
+		{Target: "b", Priority: 15, Weight: 0},
+	}
+
+	// Within priority 10, records are ordered [a2 (0), a1 (60), a3 (40)] before selection.
+	inputs := [][]int{
+		{0, 0, 0, 0, 0},
+		{100, 0, 0, 0, 0},
+		{1, 40, 0, 0, 0},
+	}
+	expectedValues := [][]string{
+		{"a2", "a1", "a3", "b", "c"},
+		{"a3", "a2", "a1", "b", "c"},
+		{"a1", "a3", "a2", "b", "c"},
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		calls := 0
+		res := orderSRV(records, func(n int) int {
+			v := inputs[i][calls]
+			calls++
+			if v >= n {
+				t.Fatalf("Value %d: Expected random number < %d got %d.", i, n, v)
+			}
+			return v
+		})
+
+		for j := range res {
+			if res[j].Target != expectedValues[i][j] {
+				t.Errorf("Value %d: Expected %v got target %s at %d.", i, expectedValues[i], res[j].Target, j)
+				break
+			}
+		}
+	}
+}
+
+// closedTCPPort returns a local port on which nothing listens.
+func closedTCPPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	return port
+}
+
+// fakeResolver is a Resolver always answering the same records.
+type fakeResolver struct {
+	records []*net.SRV
+	err     error
+}
+
+func (r fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	return "", r.records, r.err
+}
+
+func TestDialTCPSRVFailover(t *testing.T) {
+	l, port := silentTCPListener(t)
+	defer l.Close()
+	deadPort := closedTCPPort(t)
+
+	resolver := fakeResolver{records: []*net.SRV{
+		{Target: "127.0.0.1.", Port: uint16(port), Priority: 2},
+		{Target: "127.0.0.1.", Port: uint16(deadPort), Priority: 1},
+	}}
+
+	conn, err := DialTCP("mc.example.com", 25565, DialTCPOptions{Resolver: resolver})
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+
+	expectedValue := Target{Hostname: "127.0.0.1", Port: port, SRV: true}
+	if conn.Target() != expectedValue {
+		t.Errorf("Expected %v got %v.", expectedValue, conn.Target())
+	}
+}
+
+func TestDialTCPDirectFallback(t *testing.T) {
+	l, port := silentTCPListener(t)
+	defer l.Close()
+	deadPort := closedTCPPort(t)
+
+	notFound := &net.DNSError{Err: "no such host", IsNotFound: true}
+	inputs := []struct {
+		records  []*net.SRV
+		err      error
+		fallback DirectFallback
+	}{
+		{nil, notFound, FallbackWithoutSRVRecord},
+		{nil, notFound, FallbackNever},
+		{[]*net.SRV{{Target: "127.0.0.1", Port: uint16(deadPort)}}, nil, FallbackWithoutSRVRecord},
+		{[]*net.SRV{{Target: "127.0.0.1", Port: uint16(deadPort)}}, nil, FallbackAlways},
+		{[]*net.SRV{{Target: "."}}, nil, FallbackAlways},
+	}
+	expectedValues := []Target{
+		{Hostname: "127.0.0.1", Port: port},
+		{},
+		{},
+		{Hostname: "127.0.0.1", Port: port},
+		{},
+	}
+	expectedErrors := []error{nil, ErrNoSRVRecord, nil, nil, ErrSRVServiceUnavailable}
+
+	for i := 0; i < len(inputs); i++ {
+		resolver := fakeResolver{records: inputs[i].records, err: inputs[i].err}
+
+		conn, err := DialTCP("127.0.0.1", port, DialTCPOptions{DirectFallback: inputs[i].fallback, Resolver: resolver})
+		if expectedValues[i] == (Target{}) {
+			if err == nil {
+				conn.Close()
+				t.Errorf("Value %d: Expected error got <nil>.", i)
+			} else if expectedErrors[i] != nil && !errors.Is(err, expectedErrors[i]) {
+				t.Errorf("Value %d: Expected %v got %v.", i, expectedErrors[i], err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Value %d: Expected <nil> got %v.", i, err)
+			continue
+		}
+		if conn.Target() != expectedValues[i] {
+			t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], conn.Target())
+		}
+		conn.Close()
+	}
+}
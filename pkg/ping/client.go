@@ -2,6 +2,7 @@ package ping
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net"
@@ -22,12 +23,12 @@ var (
 )
 
 // generateHandshakeRequest generates a networking.Output corresponding to a handshake request.
-func generateHandshakeRequest(hostname string, port uint16) networking.Output {
+func generateHandshakeRequest(hostname string, port uint16, protocolVersion int32) networking.Output {
 	out := networking.NewOutput()
 
 	out.WriteVarInt(int32(HandshakePacketID))
 
-	out.WriteVarInt(int32(UnknownProtocolVersion))
+	out.WriteVarInt(protocolVersion)
 
 	out.WriteString(hostname)
 
@@ -74,6 +75,7 @@ func parseHandshakeResponse(in networking.Input) (*handshakeResponse, error) {
 		return nil, err
 	}
 
+	hsRes.RawJSONResponse = []byte(rawJSONResponse)
 	hsRes.JSONResponse = jsonResponse
 
 	return &hsRes, nil
@@ -131,9 +133,20 @@ type PingClient struct {
 	conn     *networking.TCPConn
 
 	// options
-	SkipSRVLookup bool
-	DialTimeout   time.Duration
-	ReadTimeout   time.Duration
+	SkipSRVLookup  bool
+	DirectFallback networking.DirectFallback
+	DialTimeout    time.Duration
+	ReadTimeout    time.Duration
+	// Resolver is used for the SRV lookup, and Dialer to open the connection. They default to the ones of the net package.
+	Resolver networking.Resolver
+	Dialer   networking.Dialer
+	// ProxyProtocol is the version of the PROXY protocol header sent at the beginning of the connection, if any. Defaults to none.
+	// The source address of the header defaults to the local address of the connection.
+	ProxyProtocol           networking.ProxyProtocolVersion
+	ProxyProtocolSourceAddr *net.TCPAddr
+
+	// ProtocolVersion is the protocol version sent in the handshake (see package protocol). Defaults to UnknownProtocolVersion.
+	ProtocolVersion int32
 }
 
 // NewClient returns a well-formed *PingClient.
@@ -148,21 +161,32 @@ func NewClient(hostname string, port int) *PingClient {
 		hostname: hostname,
 		port:     port,
 
-		SkipSRVLookup: skipSRVLookup,
-		DialTimeout:   5 * time.Second,
-		ReadTimeout:   5 * time.Second,
+		SkipSRVLookup:   skipSRVLookup,
+		DialTimeout:     5 * time.Second,
+		ReadTimeout:     5 * time.Second,
+		ProtocolVersion: UnknownProtocolVersion,
 	}
 }
 
 // Connect establishes a connection via TCP.
 func (client *PingClient) Connect() error {
+	return client.ConnectContext(context.Background())
+}
+
+// ConnectContext is the same as Connect, but the connection attempt is aborted as soon as ctx is done.
+func (client *PingClient) ConnectContext(ctx context.Context) error {
 	if client.conn != nil {
 		return networking.ErrConnectionAlreadyEstablished
 	}
 
-	conn, err := networking.DialTCP(client.hostname, client.port, networking.DialTCPOptions{
-		SkipSRVLookup: client.SkipSRVLookup,
-		DialTimeout:   client.DialTimeout,
+	conn, err := networking.DialTCPContext(ctx, client.hostname, client.port, networking.DialTCPOptions{
+		SkipSRVLookup:           client.SkipSRVLookup,
+		DirectFallback:          client.DirectFallback,
+		DialTimeout:             client.DialTimeout,
+		Resolver:                client.Resolver,
+		Dialer:                  client.Dialer,
+		ProxyProtocol:           client.ProxyProtocol,
+		ProxyProtocolSourceAddr: client.ProxyProtocolSourceAddr,
 	})
 	if err != nil {
 		return err
@@ -174,15 +198,20 @@ func (client *PingClient) Connect() error {
 
 // Handshake sends a handshake request to the server, and returns the formatted result.
 func (client *PingClient) Handshake() (Handshake, error) {
+	return client.HandshakeContext(context.Background())
+}
+
+// HandshakeContext is the same as Handshake, but the request is aborted as soon as ctx is done.
+func (client *PingClient) HandshakeContext(ctx context.Context) (Handshake, error) {
 	if client.conn == nil {
 		return Handshake{}, networking.ErrConnectionNotEstablished
 	}
 
-	hsRequest := generateHandshakeRequest(client.hostname, uint16(client.port))
+	hsRequest := generateHandshakeRequest(client.hostname, uint16(client.port), client.ProtocolVersion)
 	hsRequestPacket := transformToPacket(hsRequest)
 	fullHsRequest := networking.MergeOutputs(hsRequestPacket, emptyPacket(0))
 
-	hsResponse, err := client.conn.Send(fullHsRequest)
+	hsResponse, err := client.conn.SendContext(ctx, fullHsRequest)
 	if err != nil {
 		return Handshake{}, err
 	}
@@ -204,6 +233,11 @@ func (client *PingClient) Handshake() (Handshake, error) {
 // A ping request must be done after a handshake request has already been done.
 // Latency will be returned if there is no error, or if the error occurred  during response parsing.
 func (client *PingClient) Ping() (int, error) {
+	return client.PingContext(context.Background())
+}
+
+// PingContext is the same as Ping, but the request is aborted as soon as ctx is done.
+func (client *PingClient) PingContext(ctx context.Context) (int, error) {
 	if client.conn == nil {
 		return -1, networking.ErrConnectionNotEstablished
 	}
@@ -213,7 +247,7 @@ func (client *PingClient) Ping() (int, error) {
 
 	startTime := time.Now().UnixMilli()
 
-	pingResponse, err := client.conn.Send(pingRequestPacket)
+	pingResponse, err := client.conn.SendContext(ctx, pingRequestPacket)
 	if err != nil {
 		return -1, err
 	}
@@ -245,3 +279,12 @@ func (client *PingClient) Disconnect() error {
 	client.conn = nil
 	return err
 }
+
+// Target returns the address the client is connected to, which differs from its hostname and port if it comes from a SRV record.
+func (client *PingClient) Target() (networking.Target, error) {
+	if client.conn == nil {
+		return networking.Target{}, networking.ErrConnectionNotEstablished
+	}
+
+	return client.conn.Target(), nil
+}
@@ -0,0 +1,102 @@
+package ping
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+)
+
+const (
+	FaviconDataURIPrefix string = "data:image/png;base64,"
+	FaviconSize          int    = 64
+)
+
+var (
+	ErrInvalidFaviconDataURI error = errors.New("favicon is not a base64 encoded PNG data URI")
+	ErrInvalidFaviconSize    error = errors.New("favicon is not a 64x64 image")
+)
+
+// Favicon is the favicon of a server, as sent in the ping response : a data URI containing a base64 encoded 64x64 PNG image.
+type Favicon string
+
+// NewFavicon encodes a PNG image into a Favicon. It fails if the image isn't a valid 64x64 PNG.
+func NewFavicon(pngData []byte) (Favicon, error) {
+	favicon := Favicon(FaviconDataURIPrefix + base64.StdEncoding.EncodeToString(pngData))
+
+	err := favicon.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	return favicon, nil
+}
+
+// PNG returns the raw PNG data contained in the favicon data URI.
+// Line breaks, which are inserted by some servers in the base64 data, are ignored.
+func (f Favicon) PNG() ([]byte, error) {
+	if !strings.HasPrefix(string(f), FaviconDataURIPrefix) {
+		return nil, ErrInvalidFaviconDataURI
+	}
+
+	encoded := strings.NewReplacer("\n", "", "\r", "").Replace(string(f)[len(FaviconDataURIPrefix):])
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidFaviconDataURI
+	}
+
+	return data, nil
+}
+
+// Validate checks that the favicon is a valid data URI, containing a 64x64 PNG image.
+func (f Favicon) Validate() error {
+	data, err := f.PNG()
+	if err != nil {
+		return err
+	}
+
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if config.Width != FaviconSize || config.Height != FaviconSize {
+		return ErrInvalidFaviconSize
+	}
+
+	return nil
+}
+
+// Image decodes the favicon into an image, after having validated it.
+func (f Favicon) Image() (image.Image, error) {
+	err := f.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.PNG()
+	if err != nil {
+		return nil, err
+	}
+
+	return png.Decode(bytes.NewReader(data))
+}
+
+// WriteFile writes the PNG image contained in the favicon to the named file, after having validated it.
+func (f Favicon) WriteFile(name string) error {
+	err := f.Validate()
+	if err != nil {
+		return err
+	}
+
+	data, err := f.PNG()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, data, 0644)
+}
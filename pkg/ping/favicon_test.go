@@ -0,0 +1,105 @@
+package ping
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPNG encodes a square PNG image of the given size.
+func testPNG(t *testing.T, size int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	img.Set(1, 2, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFavicon(t *testing.T) {
+	data := testPNG(t, FaviconSize)
+
+	favicon, err := NewFavicon(data)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	img, err := favicon.Image()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if r, _, _, _ := img.At(1, 2).RGBA(); r != 0xFFFF {
+		t.Errorf("Pixel: Expected %v got %v.", 0xFFFF, r)
+	}
+
+	name := filepath.Join(t.TempDir(), "favicon.png")
+	err = favicon.WriteFile(name)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	written, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if !bytes.Equal(written, data) {
+		t.Errorf("File: Expected %d bytes got %d bytes.", len(data), len(written))
+	}
+}
+
+func TestFaviconLineBreaks(t *testing.T) {
+	favicon, err := NewFavicon(testPNG(t, FaviconSize))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	var withLineBreaks []byte
+	for i, b := range []byte(favicon) {
+		if i > len(FaviconDataURIPrefix) && i%76 == 0 {
+			withLineBreaks = append(withLineBreaks, '\n')
+		}
+		withLineBreaks = append(withLineBreaks, b)
+	}
+
+	err = Favicon(withLineBreaks).Validate()
+	if err != nil {
+		t.Errorf("Expected <nil> got %v.", err)
+	}
+}
+
+func TestFaviconInvalid(t *testing.T) {
+	inputs := []Favicon{
+		"",
+		"data:image/jpeg;base64,AAAA",
+		"data:image/png;base64,!!!!",
+		"data:image/png;base64,AAAA",
+		Favicon(FaviconDataURIPrefix + base64.StdEncoding.EncodeToString(testPNG(t, 32))),
+	}
+	expectedErrors := []error{
+		ErrInvalidFaviconDataURI,
+		ErrInvalidFaviconDataURI,
+		ErrInvalidFaviconDataURI,
+		nil,
+		ErrInvalidFaviconSize,
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		err := inputs[i].Validate()
+		if err == nil {
+			t.Errorf("Error %d: Expected non nil got <nil>.", i)
+			continue
+		}
+		if expectedErrors[i] != nil && err != expectedErrors[i] {
+			t.Errorf("Error %d: Expected %v got %v.", i, expectedErrors[i], err)
+		}
+	}
+}
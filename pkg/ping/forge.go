@@ -0,0 +1,278 @@
+package ping
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+const (
+	// ForgeIgnoreServerOnlyMarker is the prefix of the version sent by Forge for mods which are not required on the client.
+	ForgeIgnoreServerOnlyMarker string = "OHNOES"
+)
+
+var (
+	ErrInvalidForgeData error = errors.New("invalid forge data")
+)
+
+// ForgeModInfoMod is a mod of ForgeModInfo.
+type ForgeModInfoMod struct {
+	ModID   string `json:"modid"`
+	Version string `json:"version"`
+}
+
+// ForgeModInfo is the modinfo property of the status, sent by Forge servers using FML1 (1.7 to 1.12).
+type ForgeModInfo struct {
+	Type    string            `json:"type"`
+	ModList []ForgeModInfoMod `json:"modList"`
+}
+
+// ForgeDataChannel is a channel of ForgeData.
+type ForgeDataChannel struct {
+	Res      string `json:"res"`
+	Version  string `json:"version"`
+	Required bool   `json:"required"`
+}
+
+// ForgeDataMod is a mod of ForgeData.
+type ForgeDataMod struct {
+	ModID     string `json:"modId"`
+	ModMarker string `json:"modmarker"`
+}
+
+// ForgeData is the forgeData property of the status, sent by Forge servers using FML2 (1.13 to 1.18.1) and FML3 (1.18.2+).
+// Since FML3, mods and channels are not listed anymore, and are instead sent in the compressed D field.
+type ForgeData struct {
+	Channels          []ForgeDataChannel `json:"channels"`
+	Mods              []ForgeDataMod     `json:"mods"`
+	FMLNetworkVersion int                `json:"fmlNetworkVersion"`
+	Truncated         bool               `json:"truncated"`
+	D                 string             `json:"d,omitempty"`
+}
+
+// ForgeMod is a mod installed on a Forge server.
+// ServerOnly is true if the mod is not required on the client, in which case Version is empty.
+type ForgeMod struct {
+	ID         string `json:"id"`
+	Version    string `json:"version"`
+	ServerOnly bool   `json:"serverOnly"`
+}
+
+// ForgeChannel is a network channel registered on a Forge server.
+type ForgeChannel struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Required bool   `json:"required"`
+}
+
+// ForgeInfos contains mods and channels informations of a Forge server, whatever the FML version it uses.
+// FMLNetworkVersion is 1 for servers using the legacy modinfo property.
+// Truncated is true if the server didn't send the full list of mods and channels, because it was too long.
+type ForgeInfos struct {
+	FMLNetworkVersion int            `json:"fmlNetworkVersion"`
+	Mods              []ForgeMod     `json:"mods"`
+	Channels          []ForgeChannel `json:"channels"`
+	Truncated         bool           `json:"truncated"`
+}
+
+// Forge extracts Forge informations from the status. If the server isn't a Forge server, ok is false.
+func (s Status) Forge() (infos ForgeInfos, ok bool, err error) {
+	if s.ForgeData != nil {
+		infos, err = s.ForgeData.forgeInfos()
+		return infos, err == nil, err
+	}
+
+	if s.ModInfo != nil {
+		return s.ModInfo.forgeInfos(), true, nil
+	}
+
+	return ForgeInfos{}, false, nil
+}
+
+// forgeInfos transforms the FML1 modinfo into ForgeInfos.
+func (mi *ForgeModInfo) forgeInfos() ForgeInfos {
+	infos := ForgeInfos{
+		FMLNetworkVersion: 1,
+		Mods:              make([]ForgeMod, 0, len(mi.ModList)),
+	}
+
+	for _, mod := range mi.ModList {
+		infos.Mods = append(infos.Mods, ForgeMod{
+			ID:      mod.ModID,
+			Version: mod.Version,
+		})
+	}
+
+	return infos
+}
+
+// forgeInfos transforms the FML2/FML3 forge data into ForgeInfos, decoding the compressed D field if present.
+func (fd *ForgeData) forgeInfos() (ForgeInfos, error) {
+	if fd.D != "" {
+		infos, err := decodeForgeOptimizedData(fd.D)
+		if err != nil {
+			return ForgeInfos{}, err
+		}
+		infos.FMLNetworkVersion = fd.FMLNetworkVersion
+		return infos, nil
+	}
+
+	infos := ForgeInfos{
+		FMLNetworkVersion: fd.FMLNetworkVersion,
+		Mods:              make([]ForgeMod, 0, len(fd.Mods)),
+		Channels:          make([]ForgeChannel, 0, len(fd.Channels)),
+		Truncated:         fd.Truncated,
+	}
+
+	for _, mod := range fd.Mods {
+		if strings.HasPrefix(mod.ModMarker, ForgeIgnoreServerOnlyMarker) {
+			infos.Mods = append(infos.Mods, ForgeMod{ID: mod.ModID, ServerOnly: true})
+		} else {
+			infos.Mods = append(infos.Mods, ForgeMod{ID: mod.ModID, Version: mod.ModMarker})
+		}
+	}
+
+	for _, channel := range fd.Channels {
+		infos.Channels = append(infos.Channels, ForgeChannel{
+			Name:     channel.Res,
+			Version:  channel.Version,
+			Required: channel.Required,
+		})
+	}
+
+	return infos, nil
+}
+
+// decodeForgeOptimizedString decodes the binary data packed into the FML3 D field.
+// Each character of the string holds 15 bits of data, and the first two characters hold the length of the data in bytes.
+func decodeForgeOptimizedString(d string) ([]byte, error) {
+	chars := []rune(d)
+	if len(chars) < 2 {
+		return nil, ErrInvalidForgeData
+	}
+
+	size := int(chars[0]&0x7FFF) | int(chars[1]&0x7FFF)<<15
+	if size > (len(chars)-2)*15/8 {
+		return nil, ErrInvalidForgeData
+	}
+
+	data := make([]byte, 0, size)
+	var buffer uint32
+	var bitsInBuffer int
+
+	for _, c := range chars[2:] {
+		for bitsInBuffer >= 8 {
+			data = append(data, byte(buffer))
+			buffer >>= 8
+			bitsInBuffer -= 8
+		}
+
+		buffer |= uint32(c&0x7FFF) << bitsInBuffer
+		bitsInBuffer += 15
+	}
+
+	for len(data) < size {
+		data = append(data, byte(buffer))
+		buffer >>= 8
+	}
+
+	return data[:size], nil
+}
+
+// decodeForgeOptimizedData decodes the FML3 D field into ForgeInfos.
+// Channels of a mod are sent without their namespace, which is the mod id.
+func decodeForgeOptimizedData(d string) (ForgeInfos, error) {
+	var infos ForgeInfos
+
+	data, err := decodeForgeOptimizedString(d)
+	if err != nil {
+		return ForgeInfos{}, err
+	}
+	in := networking.NewInput(bytes.NewReader(data))
+
+	truncated, err := in.ReadByte()
+	if err != nil {
+		return ForgeInfos{}, err
+	}
+	infos.Truncated = truncated != 0
+
+	modsCount, err := in.ReadBigEndianInt16()
+	if err != nil {
+		return ForgeInfos{}, err
+	}
+
+	infos.Mods = make([]ForgeMod, 0, modsCount)
+	for i := 0; i < int(modsCount); i++ {
+		channelsCountAndFlag, err := in.ReadVarInt()
+		if err != nil {
+			return ForgeInfos{}, err
+		}
+
+		var mod ForgeMod
+		mod.ServerOnly = channelsCountAndFlag&1 != 0
+
+		mod.ID, err = in.ReadString()
+		if err != nil {
+			return ForgeInfos{}, err
+		}
+
+		if !mod.ServerOnly {
+			mod.Version, err = in.ReadString()
+			if err != nil {
+				return ForgeInfos{}, err
+			}
+		}
+
+		for j := 0; j < int(channelsCountAndFlag>>1); j++ {
+			channel, err := readForgeChannel(&in)
+			if err != nil {
+				return ForgeInfos{}, err
+			}
+			channel.Name = mod.ID + ":" + channel.Name
+			infos.Channels = append(infos.Channels, channel)
+		}
+
+		infos.Mods = append(infos.Mods, mod)
+	}
+
+	nonModChannelsCount, err := in.ReadVarInt()
+	if err != nil {
+		return ForgeInfos{}, err
+	}
+
+	for i := 0; i < int(nonModChannelsCount); i++ {
+		channel, err := readForgeChannel(&in)
+		if err != nil {
+			return ForgeInfos{}, err
+		}
+		infos.Channels = append(infos.Channels, channel)
+	}
+
+	return infos, nil
+}
+
+// readForgeChannel reads a channel (name, version and required flag) from the FML3 D field data.
+func readForgeChannel(in *networking.Input) (ForgeChannel, error) {
+	var channel ForgeChannel
+	var err error
+
+	channel.Name, err = in.ReadString()
+	if err != nil {
+		return ForgeChannel{}, err
+	}
+
+	channel.Version, err = in.ReadString()
+	if err != nil {
+		return ForgeChannel{}, err
+	}
+
+	required, err := in.ReadByte()
+	if err != nil {
+		return ForgeChannel{}, err
+	}
+	channel.Required = required != 0
+
+	return channel, nil
+}
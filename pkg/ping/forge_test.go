@@ -0,0 +1,178 @@
+package ping
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+// encodeForgeOptimizedString packs binary data the way FML3 does for the D field (see decodeForgeOptimizedString).
+func encodeForgeOptimizedString(data []byte) string {
+	var sb strings.Builder
+	sb.WriteRune(rune(len(data) & 0x7FFF))
+	sb.WriteRune(rune(len(data) >> 15 & 0x7FFF))
+
+	var buffer uint32
+	var bitsInBuffer int
+
+	for _, b := range data {
+		if bitsInBuffer >= 15 {
+			sb.WriteRune(rune(buffer & 0x7FFF))
+			buffer >>= 15
+			bitsInBuffer -= 15
+		}
+		buffer |= uint32(b) << bitsInBuffer
+		bitsInBuffer += 8
+	}
+
+	for bitsInBuffer > 0 {
+		sb.WriteRune(rune(buffer & 0x7FFF))
+		buffer >>= 15
+		bitsInBuffer -= 15
+	}
+
+	return sb.String()
+}
+
+func TestForgeOptimizedString(t *testing.T) {
+	inputs := [][]byte{
+		{},
+		{0x01},
+		{0xFF, 0x00, 0xAB},
+		[]byte("some longer data, to use multiple characters"),
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		res, err := decodeForgeOptimizedString(encodeForgeOptimizedString(inputs[i]))
+		if err != nil {
+			t.Errorf("Error %d: Expected <nil> got %v.", i, err)
+			continue
+		}
+		if !bytes.Equal(res, inputs[i]) {
+			t.Errorf("Value %d: Expected %v got %v.", i, inputs[i], res)
+		}
+	}
+}
+
+func TestForgeFML3(t *testing.T) {
+	out := networking.NewOutput()
+	out.WriteByte(0)                 // not truncated
+	out.WriteBigEndianInt16(2)       // 2 mods
+	out.WriteVarInt(1 << 1)          // 1 channel
+	out.WriteString("examplemod")    // mod id
+	out.WriteString("1.2.3")         // mod version
+	out.WriteString("main")          // channel path
+	out.WriteString("1")             // channel version
+	out.WriteByte(1)                 // channel required
+	out.WriteVarInt(0<<1 | 1)        // no channel, server only
+	out.WriteString("serveronlymod") // mod id
+	out.WriteVarInt(1)               // 1 non mod channel
+	out.WriteString("minecraft:register")
+	out.WriteString("FML3")
+	out.WriteByte(0)
+
+	status := Status{
+		ForgeData: &ForgeData{
+			FMLNetworkVersion: 3,
+			D:                 encodeForgeOptimizedString(out.Bytes()),
+		},
+	}
+
+	infos, ok, err := status.Forge()
+	if !ok || err != nil {
+		t.Fatalf("Expected true, <nil> got %v, %v.", ok, err)
+	}
+
+	expected := ForgeInfos{
+		FMLNetworkVersion: 3,
+		Mods: []ForgeMod{
+			{ID: "examplemod", Version: "1.2.3"},
+			{ID: "serveronlymod", ServerOnly: true},
+		},
+		Channels: []ForgeChannel{
+			{Name: "examplemod:main", Version: "1", Required: true},
+			{Name: "minecraft:register", Version: "FML3"},
+		},
+	}
+	assertForgeInfos(t, expected, infos)
+}
+
+func TestForgeFML2(t *testing.T) {
+	status, err := ParseStatus([]byte(`{
+		"description": "",
+		"forgeData": {
+			"channels": [{"res": "minecraft:unregister", "version": "FML2", "required": true}],
+			"mods": [{"modId": "forge", "modmarker": "ANY"}, {"modId": "serveronlymod", "modmarker": "OHNOES😱😱"}],
+			"fmlNetworkVersion": 2
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	infos, ok, err := status.Forge()
+	if !ok || err != nil {
+		t.Fatalf("Expected true, <nil> got %v, %v.", ok, err)
+	}
+
+	expected := ForgeInfos{
+		FMLNetworkVersion: 2,
+		Mods: []ForgeMod{
+			{ID: "forge", Version: "ANY"},
+			{ID: "serveronlymod", ServerOnly: true},
+		},
+		Channels: []ForgeChannel{
+			{Name: "minecraft:unregister", Version: "FML2", Required: true},
+		},
+	}
+	assertForgeInfos(t, expected, infos)
+}
+
+func TestForgeFML1(t *testing.T) {
+	status, err := ParseStatus([]byte(`{"description": "", "modinfo": {"type": "FML", "modList": [{"modid": "mcp", "version": "9.42"}]}}`))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	infos, ok, err := status.Forge()
+	if !ok || err != nil {
+		t.Fatalf("Expected true, <nil> got %v, %v.", ok, err)
+	}
+
+	expected := ForgeInfos{
+		FMLNetworkVersion: 1,
+		Mods:              []ForgeMod{{ID: "mcp", Version: "9.42"}},
+	}
+	assertForgeInfos(t, expected, infos)
+}
+
+func TestForgeVanilla(t *testing.T) {
+	_, ok, err := Status{}.Forge()
+	if ok || err != nil {
+		t.Errorf("Expected false, <nil> got %v, %v.", ok, err)
+	}
+}
+
+// assertForgeInfos reports an error if res is different from expected.
+func assertForgeInfos(t *testing.T, expected, res ForgeInfos) {
+	t.Helper()
+
+	if res.FMLNetworkVersion != expected.FMLNetworkVersion || res.Truncated != expected.Truncated {
+		t.Errorf("Expected %v got %v.", expected, res)
+	}
+	if len(res.Mods) != len(expected.Mods) || len(res.Channels) != len(expected.Channels) {
+		t.Fatalf("Expected %v got %v.", expected, res)
+	}
+	for i := range res.Mods {
+		if res.Mods[i] != expected.Mods[i] {
+			t.Errorf("Mod %d: Expected %v got %v.", i, expected.Mods[i], res.Mods[i])
+		}
+	}
+	for i := range res.Channels {
+		if res.Channels[i] != expected.Channels[i] {
+			t.Errorf("Channel %d: Expected %v got %v.", i, expected.Channels[i], res.Channels[i])
+		}
+	}
+}
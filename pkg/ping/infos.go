@@ -1,5 +1,11 @@
 package ping
 
+import (
+	"encoding/json"
+
+	"github.com/xrjr/mcutils/pkg/chat"
+)
+
 // infosVersion is version type of Infos.
 type infosVersion struct {
 	Name     string `json:"name"`
@@ -20,12 +26,14 @@ type infosPlayers struct {
 }
 
 // Infos represents usual informations contained in ping response.
+// Description is the plain text of the whole description text component, which is available in DescriptionComponent.
 type Infos struct {
-	Version            infosVersion `json:"version"`
-	Players            infosPlayers `json:"players"`
-	Description        string       `json:"description"`
-	Favicon            string       `json:"favicon"`
-	EnforcesSecureChat bool         `json:"enforcesSecureChat"`
+	Version              infosVersion   `json:"version"`
+	Players              infosPlayers   `json:"players"`
+	Description          string         `json:"description"`
+	DescriptionComponent chat.Component `json:"descriptionComponent"`
+	Favicon              string         `json:"favicon"`
+	EnforcesSecureChat   bool           `json:"enforcesSecureChat"`
 }
 
 // Infos extracts informations from ping response properties (JSON), and put it into an Infos structure.
@@ -109,19 +117,12 @@ func (m *JSON) Infos() Infos {
 
 	description, ok := (*m)["description"]
 	if ok {
-		descriptionString, ok := description.(string)
-		if ok {
-			infos.Description = descriptionString
-		} else {
-			descriptionJSON, ok := description.(map[string]interface{})
-			if ok {
-				text, ok := descriptionJSON["text"]
-				if ok {
-					textString, ok := text.(string)
-					if ok {
-						infos.Description = textString
-					}
-				}
+		rawDescription, err := json.Marshal(description)
+		if err == nil {
+			descriptionComponent, err := chat.Parse(rawDescription)
+			if err == nil {
+				infos.DescriptionComponent = descriptionComponent
+				infos.Description = descriptionComponent.PlainText()
 			}
 		}
 	}
@@ -2,6 +2,7 @@ package ping
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"net"
 	"strconv"
@@ -178,9 +179,17 @@ type PingClientLegacy struct {
 	conn     *networking.TCPConn
 
 	// options
-	SkipSRVLookup bool
-	DialTimeout   time.Duration
-	ReadTimeout   time.Duration
+	SkipSRVLookup  bool
+	DirectFallback networking.DirectFallback
+	DialTimeout    time.Duration
+	ReadTimeout    time.Duration
+	// Resolver is used for the SRV lookup, and Dialer to open the connection. They default to the ones of the net package.
+	Resolver networking.Resolver
+	Dialer   networking.Dialer
+	// ProxyProtocol is the version of the PROXY protocol header sent at the beginning of the connection, if any. Defaults to none.
+	// The source address of the header defaults to the local address of the connection.
+	ProxyProtocol           networking.ProxyProtocolVersion
+	ProxyProtocolSourceAddr *net.TCPAddr
 }
 
 // NewClientLegacy returns a well-formed *LegacyPingClient.
@@ -203,13 +212,23 @@ func NewClientLegacy(hostname string, port int) *PingClientLegacy {
 
 // Connect establishes a connection via TCP.
 func (client *PingClientLegacy) Connect() error {
+	return client.ConnectContext(context.Background())
+}
+
+// ConnectContext is the same as Connect, but the connection attempt is aborted as soon as ctx is done.
+func (client *PingClientLegacy) ConnectContext(ctx context.Context) error {
 	if client.conn != nil {
 		return networking.ErrConnectionAlreadyEstablished
 	}
 
-	conn, err := networking.DialTCP(client.hostname, client.port, networking.DialTCPOptions{
-		SkipSRVLookup: client.SkipSRVLookup,
-		DialTimeout:   client.DialTimeout,
+	conn, err := networking.DialTCPContext(ctx, client.hostname, client.port, networking.DialTCPOptions{
+		SkipSRVLookup:           client.SkipSRVLookup,
+		DirectFallback:          client.DirectFallback,
+		DialTimeout:             client.DialTimeout,
+		Resolver:                client.Resolver,
+		Dialer:                  client.Dialer,
+		ProxyProtocol:           client.ProxyProtocol,
+		ProxyProtocolSourceAddr: client.ProxyProtocolSourceAddr,
 	})
 	if err != nil {
 		return err
@@ -223,17 +242,27 @@ func (client *PingClientLegacy) Connect() error {
 // If the minecraft server has a version <= 1.3, ProtocolNumber and MinecraftVersion are not set.
 // Note that legacy ping should be working on most servers that don't require host to be set, but it is notoriously slow on 1.6.x vanilla servers.
 func (client *PingClientLegacy) Ping() (LegacyPingInfos, int, error) {
-	return client.ping(false)
+	return client.ping(context.Background(), false)
+}
+
+// PingContext is the same as Ping, but the request is aborted as soon as ctx is done.
+func (client *PingClientLegacy) PingContext(ctx context.Context) (LegacyPingInfos, int, error) {
+	return client.ping(ctx, false)
 }
 
 // Ping1_6_4 sends a legacy ping request to the server (using 1.6+ SLP protocol), and returns various informations about the server, and the latency in ms.
 // Note that on vanilla servers < 1.4.x, this protocol usually don't work.
 func (client *PingClientLegacy) Ping1_6_4() (LegacyPingInfos, int, error) {
-	return client.ping(true)
+	return client.ping(context.Background(), true)
+}
+
+// Ping1_6_4Context is the same as Ping1_6_4, but the request is aborted as soon as ctx is done.
+func (client *PingClientLegacy) Ping1_6_4Context(ctx context.Context) (LegacyPingInfos, int, error) {
+	return client.ping(ctx, true)
 }
 
 // ping sends a legacy ping request to the server, and returns various informations about the server, and the latency in ms.
-func (client *PingClientLegacy) ping(use1_6_4protocol bool) (LegacyPingInfos, int, error) {
+func (client *PingClientLegacy) ping(ctx context.Context, use1_6_4protocol bool) (LegacyPingInfos, int, error) {
 	if client.conn == nil {
 		return LegacyPingInfos{}, -1, networking.ErrConnectionNotEstablished
 	}
@@ -241,7 +270,7 @@ func (client *PingClientLegacy) ping(use1_6_4protocol bool) (LegacyPingInfos, in
 	pingRequest := generateLegacyPingRequest(client.hostname, uint16(client.port), use1_6_4protocol)
 
 	start := time.Now().UnixMilli()
-	pingResponse, err := client.conn.Send(pingRequest)
+	pingResponse, err := client.conn.SendContext(ctx, pingRequest)
 	if err != nil {
 		return LegacyPingInfos{}, -1, err
 	}
@@ -271,3 +300,12 @@ func (client *PingClientLegacy) Disconnect() error {
 	client.conn = nil
 	return err
 }
+
+// Target returns the address the client is connected to, which differs from its hostname and port if it comes from a SRV record.
+func (client *PingClientLegacy) Target() (networking.Target, error) {
+	if client.conn == nil {
+		return networking.Target{}, networking.ErrConnectionNotEstablished
+	}
+
+	return client.conn.Target(), nil
+}
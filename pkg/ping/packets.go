@@ -3,6 +3,7 @@ package ping
 import (
 	"errors"
 
+	"github.com/xrjr/mcutils/pkg/chat"
 	"github.com/xrjr/mcutils/pkg/networking"
 )
 
@@ -22,19 +23,31 @@ type packet struct {
 // handshakeResponse is the type respresenting the response of the handshake request.
 type handshakeResponse struct {
 	packet
-	JSONResponse map[string]interface{}
+	RawJSONResponse []byte
+	JSONResponse    map[string]interface{}
 }
 
 // handshake transforms the handshakeResponse into a more human-usable Handshake struct.
 func (hsr *handshakeResponse) handshake() Handshake {
 	return Handshake{
 		Properties: hsr.JSONResponse,
+		raw:        hsr.RawJSONResponse,
 	}
 }
 
 // Handshake contains SLP handshake informations.
 type Handshake struct {
 	Properties JSON `json:"properties"`
+	raw        []byte
+}
+
+// Status decodes the handshake response into a Status.
+// Unlike Properties, which accepts any JSON object, it fails if a known property doesn't have the expected type.
+func (hs Handshake) Status() (Status, error) {
+	if hs.raw == nil {
+		return Status{}, ErrMalformedPacket
+	}
+	return ParseStatus(hs.raw)
 }
 
 // pongResponse is the type respresenting the response of the ping request.
@@ -90,3 +103,17 @@ type LegacyPingInfos struct {
 	OnlinePlayers    int    `json:"onlinePlayers"`
 	MaxPlayers       int    `json:"maxPlayers"`
 }
+
+// MOTDComponent converts the MOTD, which may contain legacy formatting codes, into a text component.
+func (lpi LegacyPingInfos) MOTDComponent() chat.Component {
+	return chat.FromLegacy(lpi.MOTD)
+}
+
+// handshakeRequest is the type respresenting the handshake request, as received by the server.
+type handshakeRequest struct {
+	packet
+	ProtocolVersion int32
+	Hostname        string
+	Port            uint16
+	NextState       uint32
+}
@@ -2,25 +2,34 @@
 // This package is strictly compliant with the following documentation : https://minecraft.wiki/w/Java_Edition_protocol/Server_List_Ping.
 package ping
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 // Ping returns the server list ping infos (JSON-like object), and latency of a minecraft server.
 // If an error occurred at any point of the process, an nil json response, a latency of -1, and a non nil error are returned.
 // If the server responds to the ping request with a bad packet (e.g. with a handshake response), the packet will not be read and the error will be ingored (to support Forge servers).
 func Ping(hostname string, port int) (JSON, int, error) {
+	return PingContext(context.Background(), hostname, port)
+}
+
+// PingContext is the same as Ping, but the whole process is aborted as soon as ctx is done.
+func PingContext(ctx context.Context, hostname string, port int) (JSON, int, error) {
 	client := NewClient(hostname, port)
 
-	err := client.Connect()
+	err := client.ConnectContext(ctx)
 	if err != nil {
 		return nil, -1, err
 	}
+	defer client.Disconnect()
 
-	handshake, err := client.Handshake()
+	handshake, err := client.HandshakeContext(ctx)
 	if err != nil {
 		return nil, -1, err
 	}
 
-	latency, err := client.Ping()
+	latency, err := client.PingContext(ctx)
 
 	// Some forge servers respond to ping request with the handshake response. In this case, a ErrInvalidPacketType will be returned.
 	// We'll be ingoring this error because it doesn't have any side effect, since :
@@ -30,12 +39,44 @@ func Ping(hostname string, port int) (JSON, int, error) {
 		return nil, -1, err
 	}
 
-	err = client.Disconnect()
+	return handshake.Properties, latency, nil
+}
+
+// PingStatus is the same as Ping, but it also returns the ping infos decoded into a Status.
+// The JSON-like object is returned alongside for backward compatibility.
+// If an error occurred at any point of the process, an empty status, a nil json response, a latency of -1, and a non nil error are returned.
+func PingStatus(hostname string, port int) (Status, JSON, int, error) {
+	return PingStatusContext(context.Background(), hostname, port)
+}
+
+// PingStatusContext is the same as PingStatus, but the whole process is aborted as soon as ctx is done.
+func PingStatusContext(ctx context.Context, hostname string, port int) (Status, JSON, int, error) {
+	client := NewClient(hostname, port)
+
+	err := client.ConnectContext(ctx)
 	if err != nil {
-		return nil, -1, err
+		return Status{}, nil, -1, err
 	}
+	defer client.Disconnect()
 
-	return handshake.Properties, latency, nil
+	handshake, err := client.HandshakeContext(ctx)
+	if err != nil {
+		return Status{}, nil, -1, err
+	}
+
+	status, err := handshake.Status()
+	if err != nil {
+		return Status{}, nil, -1, err
+	}
+
+	latency, err := client.PingContext(ctx)
+
+	// See Ping for why ErrInvalidPacketType is ignored.
+	if err != nil && !errors.Is(err, ErrInvalidPacketType) {
+		return Status{}, nil, -1, err
+	}
+
+	return status, handshake.Properties, latency, nil
 }
 
 // PingLegacy returns the legacy server list ping infos, and latency of a minecraft server.
@@ -43,19 +84,20 @@ func Ping(hostname string, port int) (JSON, int, error) {
 // If the minecraft server has a version <= 1.3, ProtocolNumber and MinecraftVersion are not set.
 // Note that legacy ping should be working on most servers that don't require host to be set, but it is notoriously slow on 1.6.x vanilla servers.
 func PingLegacy(hostname string, port int) (LegacyPingInfos, int, error) {
-	client := NewClientLegacy(hostname, port)
+	return PingLegacyContext(context.Background(), hostname, port)
+}
 
-	err := client.Connect()
-	if err != nil {
-		return LegacyPingInfos{}, -1, err
-	}
+// PingLegacyContext is the same as PingLegacy, but the whole process is aborted as soon as ctx is done.
+func PingLegacyContext(ctx context.Context, hostname string, port int) (LegacyPingInfos, int, error) {
+	client := NewClientLegacy(hostname, port)
 
-	infos, latency, err := client.Ping()
+	err := client.ConnectContext(ctx)
 	if err != nil {
 		return LegacyPingInfos{}, -1, err
 	}
+	defer client.Disconnect()
 
-	err = client.Disconnect()
+	infos, latency, err := client.PingContext(ctx)
 	if err != nil {
 		return LegacyPingInfos{}, -1, err
 	}
@@ -67,19 +109,20 @@ func PingLegacy(hostname string, port int) (LegacyPingInfos, int, error) {
 // If an error occurred at any point of the process, an empty response, a latency of -1, and a non nil error are returned.
 // Note that on vanilla servers < 1.4.x, this protocol usually don't work.
 func PingLegacy1_6_4(hostname string, port int) (LegacyPingInfos, int, error) {
-	client := NewClientLegacy(hostname, port)
+	return PingLegacy1_6_4Context(context.Background(), hostname, port)
+}
 
-	err := client.Connect()
-	if err != nil {
-		return LegacyPingInfos{}, -1, err
-	}
+// PingLegacy1_6_4Context is the same as PingLegacy1_6_4, but the whole process is aborted as soon as ctx is done.
+func PingLegacy1_6_4Context(ctx context.Context, hostname string, port int) (LegacyPingInfos, int, error) {
+	client := NewClientLegacy(hostname, port)
 
-	infos, latency, err := client.Ping1_6_4()
+	err := client.ConnectContext(ctx)
 	if err != nil {
 		return LegacyPingInfos{}, -1, err
 	}
+	defer client.Disconnect()
 
-	err = client.Disconnect()
+	infos, latency, err := client.Ping1_6_4Context(ctx)
 	if err != nil {
 		return LegacyPingInfos{}, -1, err
 	}
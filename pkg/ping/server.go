@@ -0,0 +1,427 @@
+package ping
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/chat"
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+const (
+	DefaultServerAddr           string = ":25565"
+	LegacyPingPacketIdentifier  byte   = 0xFE
+	LegacyServerProtocolVersion int    = 127 // protocol version sent by modern vanilla servers in legacy ping responses
+	// MaximumServerPacketLength is the maximum length of the packets accepted by the server.
+	MaximumServerPacketLength int = 32767
+
+	// legacyDetectionTimeout is the time given to legacy clients to send the rest of their request after the first byte.
+	legacyDetectionTimeout time.Duration = 250 * time.Millisecond
+)
+
+var (
+	ErrServerClosed error = errors.New("ping server is closed")
+)
+
+// readServerPacket reads a packet sent by a client, and returns its id and its content (after the id).
+func readServerPacket(in networking.Input) (uint32, networking.Input, error) {
+	length, err := in.ReadVarInt()
+	if err != nil {
+		return 0, networking.Input{}, err
+	}
+
+	if length <= 0 || int(length) > MaximumServerPacketLength {
+		return 0, networking.Input{}, ErrMalformedPacket
+	}
+
+	content, err := in.ReadBytes(int(length))
+	if err != nil {
+		return 0, networking.Input{}, err
+	}
+	_in := networking.NewInput(bytes.NewReader(content))
+
+	packetID, err := _in.ReadVarInt()
+	if err != nil {
+		return 0, networking.Input{}, err
+	}
+
+	return uint32(packetID), _in, nil
+}
+
+// parseHandshakeRequest parses the content of a handshake request into a *handshakeRequest.
+func parseHandshakeRequest(in networking.Input) (*handshakeRequest, error) {
+	var hsReq handshakeRequest
+	hsReq.PacketID = HandshakePacketID
+
+	protocolVersion, err := in.ReadVarInt()
+	if err != nil {
+		return nil, err
+	}
+	hsReq.ProtocolVersion = protocolVersion
+
+	hostname, err := in.ReadString()
+	if err != nil {
+		return nil, err
+	}
+	hsReq.Hostname = hostname
+
+	port, err := in.ReadBigEndianInt16()
+	if err != nil {
+		return nil, err
+	}
+	hsReq.Port = port
+
+	nextState, err := in.ReadVarInt()
+	if err != nil {
+		return nil, err
+	}
+	hsReq.NextState = uint32(nextState)
+
+	return &hsReq, nil
+}
+
+// generateStatusResponse generates a networking.Output corresponding to a status response.
+func generateStatusResponse(rawJSON []byte) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteVarInt(int32(HandshakePacketID))
+
+	out.WriteString(string(rawJSON))
+
+	return out
+}
+
+// generatePongResponse generates a networking.Output corresponding to a pong response.
+func generatePongResponse(payload int64) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteVarInt(int32(PingPacketID))
+
+	out.WriteBigEndianInt64(uint64(payload))
+
+	return out
+}
+
+// generateLegacyPingResponse generates a networking.Output corresponding to a legacy ping response.
+// The post 1.3 format (understood by 1.4+ clients) contains the protocol and minecraft versions, the pre 1.3 one only the MOTD and the players.
+func generateLegacyPingResponse(infos LegacyPingInfos, post1_3 bool) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteByte(SingleByteIdentifierValue)
+
+	var payload []byte
+	if post1_3 {
+		payload = append(payload, Post1_3Padding[:]...)
+		payload = append(payload, stringToBigEndianUTF16(strconv.Itoa(infos.ProtocolVersion))...)
+		payload = append(payload, Post1_3Delimiter[:]...)
+		payload = append(payload, stringToBigEndianUTF16(infos.MinecraftVersion)...)
+		payload = append(payload, Post1_3Delimiter[:]...)
+		payload = append(payload, stringToBigEndianUTF16(infos.MOTD)...)
+		payload = append(payload, Post1_3Delimiter[:]...)
+		payload = append(payload, stringToBigEndianUTF16(strconv.Itoa(infos.OnlinePlayers))...)
+		payload = append(payload, Post1_3Delimiter[:]...)
+		payload = append(payload, stringToBigEndianUTF16(strconv.Itoa(infos.MaxPlayers))...)
+	} else {
+		// The delimiter is §, so the MOTD can't contain formatting codes.
+		payload = append(payload, stringToBigEndianUTF16(chat.StripLegacy(infos.MOTD))...)
+		payload = append(payload, Pre1_3Delimiter[:]...)
+		payload = append(payload, stringToBigEndianUTF16(strconv.Itoa(infos.OnlinePlayers))...)
+		payload = append(payload, Pre1_3Delimiter[:]...)
+		payload = append(payload, stringToBigEndianUTF16(strconv.Itoa(infos.MaxPlayers))...)
+	}
+
+	out.WriteBigEndianInt16(uint16(len(payload) / 2))
+
+	out.WriteBytes(payload)
+
+	return out
+}
+
+// Server is a server list ping server, answering status requests (modern and legacy) with a fixed status, and closing other connections.
+// It can be used to show a server in the server list while it isn't running, e.g. during maintenance.
+type Server struct {
+	mutex    sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+
+	// options
+
+	// Addr is the TCP address listened by ListenAndServe. Defaults to DefaultServerAddr.
+	Addr string
+	// Status is the status sent to clients, unless RawStatus is set. Legacy ping responses are built from it too.
+	// Options must not be modified while the server is running.
+	Status Status
+	// RawStatus is the raw JSON status sent to clients. If nil, Status is used.
+	RawStatus []byte
+	// Timeout is the maximum duration of a connection.
+	Timeout time.Duration
+	// ProxyProtocol makes the server require a PROXY protocol header (v1 or v2) at the beginning of each connection, as sent by proxies.
+	// The remote address of connections is then the source address of the header.
+	ProxyProtocol bool
+}
+
+// NewServer returns a well-formed *Server.
+func NewServer(addr string, status Status) *Server {
+	return &Server{
+		conns: make(map[net.Conn]struct{}),
+
+		Addr:    addr,
+		Status:  status,
+		Timeout: 5 * time.Second,
+	}
+}
+
+// ListenAndServe listens on Addr, and serves connections until the server is closed. It always returns a non-nil error, ErrServerClosed after Close.
+func (server *Server) ListenAndServe() error {
+	addr := server.Addr
+	if addr == "" {
+		addr = DefaultServerAddr
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return server.Serve(l)
+}
+
+// Serve serves the connections accepted by l until the server is closed. l is closed when Serve returns.
+// It always returns a non-nil error, ErrServerClosed after Close.
+func (server *Server) Serve(l net.Listener) error {
+	server.mutex.Lock()
+	if server.closed {
+		server.mutex.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	if server.ProxyProtocol {
+		l = networking.NewProxyProtocolListener(l)
+	}
+	if server.conns == nil {
+		server.conns = make(map[net.Conn]struct{})
+	}
+	server.listener = l
+	server.mutex.Unlock()
+
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			server.mutex.Lock()
+			defer server.mutex.Unlock()
+
+			if server.closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		if !server.track(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		go server.serveConn(conn)
+	}
+}
+
+// Close stops listening, and closes all the connections.
+func (server *Server) Close() error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.closed {
+		return ErrServerClosed
+	}
+	server.closed = true
+
+	var err error
+	if server.listener != nil {
+		err = server.listener.Close()
+	}
+
+	for conn := range server.conns {
+		conn.Close()
+	}
+
+	return err
+}
+
+// track registers conn, to close it with the server. It returns false if the server is closed.
+func (server *Server) track(conn net.Conn) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.closed {
+		return false
+	}
+
+	server.conns[conn] = struct{}{}
+	return true
+}
+
+// serveConn answers the requests of a connection, legacy or modern depending on its first byte, and closes it.
+func (server *Server) serveConn(conn net.Conn) {
+	defer func() {
+		server.mutex.Lock()
+		delete(server.conns, conn)
+		server.mutex.Unlock()
+
+		conn.Close()
+	}()
+
+	if server.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(server.Timeout))
+	}
+
+	reader := bufio.NewReader(conn)
+
+	first, err := reader.Peek(1)
+	if err != nil {
+		return
+	}
+
+	if first[0] == LegacyPingPacketIdentifier {
+		server.serveLegacy(conn, reader)
+	} else {
+		server.serveModern(conn, reader)
+	}
+}
+
+// serveModern answers a handshake, followed by a status request and/or a ping request.
+// The connection is closed after the pong response, or if the client asks for another state than status.
+func (server *Server) serveModern(conn net.Conn, reader *bufio.Reader) {
+	in := networking.NewInput(reader)
+
+	packetID, content, err := readServerPacket(in)
+	if err != nil || packetID != HandshakePacketID {
+		return
+	}
+
+	hs, err := parseHandshakeRequest(content)
+	if err != nil || hs.NextState != NextStateStatus {
+		return
+	}
+
+	statusSent := false
+	for {
+		packetID, content, err := readServerPacket(in)
+		if err != nil {
+			return
+		}
+
+		switch packetID {
+		case HandshakePacketID:
+			// A status request can only be sent once.
+			if statusSent {
+				return
+			}
+			statusSent = true
+
+			rawJSON, err := server.statusJSON()
+			if err != nil {
+				return
+			}
+
+			statusResponse := generateStatusResponse(rawJSON)
+			err = writePacket(conn, transformToPacket(statusResponse))
+			if err != nil {
+				return
+			}
+		case PingPacketID:
+			payload, err := content.ReadBigEndianInt64()
+			if err != nil {
+				return
+			}
+
+			pongResponse := generatePongResponse(int64(payload))
+			writePacket(conn, transformToPacket(pongResponse))
+			return
+		default:
+			return
+		}
+	}
+}
+
+// serveLegacy answers a legacy ping : 0xFE (pre 1.4), 0xFE 0x01 (1.4 and 1.5), or 0xFE 0x01 followed by a MC|PingHost plugin message (1.6).
+func (server *Server) serveLegacy(conn net.Conn, reader *bufio.Reader) {
+	reader.Discard(1)
+
+	conn.SetReadDeadline(time.Now().Add(legacyDetectionTimeout))
+
+	b, err := reader.ReadByte()
+	if err != nil || b != CommonLegacyRequest[1] {
+		writePacket(conn, generateLegacyPingResponse(server.legacyPingInfos(), false))
+		return
+	}
+
+	// 1.6 clients send a plugin message, which is read so that closing the connection doesn't reset it before the response is received.
+	b, err = reader.ReadByte()
+	if err == nil && b == PluginMessagePacketIdentifier {
+		if server.Timeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(server.Timeout))
+		}
+
+		in := networking.NewInput(reader)
+
+		channelLength, err := in.ReadBigEndianInt16()
+		if err != nil {
+			return
+		}
+		_, err = in.ReadBytes(int(channelLength) * 2)
+		if err != nil {
+			return
+		}
+
+		dataLength, err := in.ReadBigEndianInt16()
+		if err != nil {
+			return
+		}
+		_, err = in.ReadBytes(int(dataLength))
+		if err != nil {
+			return
+		}
+	}
+
+	writePacket(conn, generateLegacyPingResponse(server.legacyPingInfos(), true))
+}
+
+// statusJSON returns the raw JSON status sent to clients.
+func (server *Server) statusJSON() ([]byte, error) {
+	if server.RawStatus != nil {
+		return server.RawStatus, nil
+	}
+
+	return json.Marshal(server.Status)
+}
+
+// legacyPingInfos returns the infos sent to legacy clients, built from the status.
+func (server *Server) legacyPingInfos() LegacyPingInfos {
+	status := server.Status
+	if server.RawStatus != nil {
+		status, _ = ParseStatus(server.RawStatus)
+	}
+
+	return LegacyPingInfos{
+		ProtocolVersion:  LegacyServerProtocolVersion,
+		MinecraftVersion: status.Version.Name,
+		MOTD:             status.Description.Legacy(),
+		OnlinePlayers:    status.Players.Online,
+		MaxPlayers:       status.Players.Max,
+	}
+}
+
+// writePacket writes an output in a single write.
+func writePacket(conn net.Conn, out networking.Output) error {
+	_, err := conn.Write(out.Bytes())
+	return err
+}
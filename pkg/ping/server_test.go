@@ -0,0 +1,152 @@
+package ping
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/chat"
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+// startTestServer starts a ping server answering with status, and returns its port.
+func startTestServer(t *testing.T, server *Server) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	go server.Serve(l)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+var testServerStatus = Status{
+	Version: StatusVersion{Name: "Maintenance", Protocol: -1},
+	Players: StatusPlayers{
+		Max:    20,
+		Online: 1,
+		Sample: []StatusPlayer{{Name: "Notch", ID: "069a79f4-44e9-4726-a5be-fca90e38aaf5"}},
+	},
+	Description: chat.FromLegacy("§cDown for maintenance"),
+	Favicon:     Favicon("data:image/png;base64,AAAA"),
+}
+
+func TestServerStatus(t *testing.T) {
+	port := startTestServer(t, NewServer("", testServerStatus))
+
+	status, properties, latency, err := PingStatus("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	if status.Version != testServerStatus.Version {
+		t.Errorf("Version: Expected %v got %v.", testServerStatus.Version, status.Version)
+	}
+	if status.Players.Max != 20 || status.Players.Online != 1 || len(status.Players.Sample) != 1 || status.Players.Sample[0] != testServerStatus.Players.Sample[0] {
+		t.Errorf("Players: Expected %v got %v.", testServerStatus.Players, status.Players)
+	}
+	if status.Description.Legacy() != testServerStatus.Description.Legacy() {
+		t.Errorf("Description: Expected %s got %s.", testServerStatus.Description.Legacy(), status.Description.Legacy())
+	}
+	if status.Favicon != testServerStatus.Favicon {
+		t.Errorf("Favicon: Expected %s got %s.", testServerStatus.Favicon, status.Favicon)
+	}
+	if properties == nil || latency < 0 {
+		t.Errorf("Expected properties and latency got %v, %d.", properties, latency)
+	}
+
+	server := NewServer("", Status{})
+	server.RawStatus = []byte(`{"version":{"name":"raw","protocol":4},"players":{"max":1,"online":0},"description":"raw"}`)
+	port = startTestServer(t, server)
+
+	properties, _, err = Ping("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if properties.Infos().Version.Name != "raw" || properties.Infos().Description != "raw" {
+		t.Errorf("Expected raw got %v.", properties)
+	}
+}
+
+func TestServerPong(t *testing.T) {
+	port := startTestServer(t, NewServer("", testServerStatus))
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	hsRequest := generateHandshakeRequest("localhost", uint16(port), 765)
+	pingRequest := networking.NewOutput()
+	pingRequest.WriteVarInt(int32(PingPacketID))
+	pingRequest.WriteBigEndianInt64(0x0123456789ABCDEF)
+
+	// The ping request can be sent without a status request.
+	request := networking.MergeOutputs(transformToPacket(hsRequest), transformToPacket(pingRequest))
+	_, err = conn.Write(request.Bytes())
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	pong, err := parsePongResponse(networking.NewInput(conn))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if pong.Payload != 0x0123456789ABCDEF {
+		t.Errorf("Expected %x got %x.", uint64(0x0123456789ABCDEF), pong.Payload)
+	}
+}
+
+func TestServerLegacy(t *testing.T) {
+	port := startTestServer(t, NewServer("", testServerStatus))
+
+	expectedValue := LegacyPingInfos{
+		ProtocolVersion:  LegacyServerProtocolVersion,
+		MinecraftVersion: "Maintenance",
+		MOTD:             testServerStatus.Description.Legacy(),
+		OnlinePlayers:    1,
+		MaxPlayers:       20,
+	}
+
+	infos, _, err := PingLegacy("127.0.0.1", port)
+	if err != nil || infos != expectedValue {
+		t.Errorf("Value 0: Expected %v, <nil> got %v, %v.", expectedValue, infos, err)
+	}
+
+	infos, _, err = PingLegacy1_6_4("127.0.0.1", port)
+	if err != nil || infos != expectedValue {
+		t.Errorf("Value 1: Expected %v, <nil> got %v, %v.", expectedValue, infos, err)
+	}
+
+	// pre 1.4 clients only send 0xFE, and receive the pre 1.3 format, without versions nor formatting codes.
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	_, err = conn.Write([]byte{LegacyPingPacketIdentifier})
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	lpr, err := parseLegacyPingResponse(networking.NewInput(conn))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	expectedValue = LegacyPingInfos{MOTD: "Down for maintenance", OnlinePlayers: 1, MaxPlayers: 20}
+	if lpr.legacyPingInfos() != expectedValue {
+		t.Errorf("Value 2: Expected %v got %v.", expectedValue, lpr.legacyPingInfos())
+	}
+}
@@ -0,0 +1,113 @@
+package ping
+
+import (
+	"encoding/json"
+
+	"github.com/xrjr/mcutils/pkg/chat"
+)
+
+// statusKnownFields are the status properties decoded into dedicated Status fields. Other properties are kept in Status.Extra.
+var statusKnownFields = []string{"version", "players", "description", "favicon", "enforcesSecureChat", "previewsChat", "forgeData", "modinfo"}
+
+// StatusVersion is the version type of Status.
+type StatusVersion struct {
+	Name     string `json:"name"`
+	Protocol int    `json:"protocol"`
+}
+
+// StatusPlayer is the player sample type of Status.Players.
+type StatusPlayer struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// StatusPlayers is the players type of Status.
+type StatusPlayers struct {
+	Max    int            `json:"max"`
+	Online int            `json:"online"`
+	Sample []StatusPlayer `json:"sample,omitempty"`
+}
+
+// Status is the fully decoded server list ping status response.
+// Properties not known by this package are kept as raw JSON in Extra, so that no information is lost.
+type Status struct {
+	Version            StatusVersion  `json:"version"`
+	Players            StatusPlayers  `json:"players"`
+	Description        chat.Component `json:"description"`
+	Favicon            Favicon        `json:"favicon,omitempty"`
+	EnforcesSecureChat bool           `json:"enforcesSecureChat,omitempty"`
+	PreviewsChat       bool           `json:"previewsChat,omitempty"`
+	ForgeData          *ForgeData     `json:"forgeData,omitempty"`
+	ModInfo            *ForgeModInfo  `json:"modinfo,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// rawStatus has the same fields as Status, without its JSON methods.
+type rawStatus Status
+
+// UnmarshalJSON decodes a status response, keeping unknown properties in Extra.
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var raw rawStatus
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	var properties map[string]json.RawMessage
+	err = json.Unmarshal(data, &properties)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range statusKnownFields {
+		delete(properties, key)
+	}
+
+	if len(properties) > 0 {
+		raw.Extra = properties
+	} else {
+		raw.Extra = nil
+	}
+
+	*s = Status(raw)
+	return nil
+}
+
+// MarshalJSON encodes a status response, including properties kept in Extra.
+func (s Status) MarshalJSON() ([]byte, error) {
+	known, err := json.Marshal(rawStatus(s))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.Extra) == 0 {
+		return known, nil
+	}
+
+	var properties map[string]json.RawMessage
+	err = json.Unmarshal(known, &properties)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range s.Extra {
+		if _, ok := properties[key]; !ok {
+			properties[key] = value
+		}
+	}
+
+	return json.Marshal(properties)
+}
+
+// ParseStatus decodes a raw status response (as sent by the server) into a Status.
+func ParseStatus(data []byte) (Status, error) {
+	var status Status
+
+	err := json.Unmarshal(data, &status)
+	if err != nil {
+		return Status{}, err
+	}
+
+	return status, nil
+}
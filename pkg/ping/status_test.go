@@ -0,0 +1,99 @@
+package ping
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testStatusJSON = `{
+	"version": {"name": "1.20.4", "protocol": 765},
+	"players": {"max": 20, "online": 2, "sample": [{"name": "Notch", "id": "069a79f4-44e9-4726-a5be-fca90e38aaf5"}]},
+	"description": {"text": "Hello ", "extra": [{"text": "world", "color": "gold"}]},
+	"favicon": "data:image/png;base64,AAAA",
+	"enforcesSecureChat": true,
+	"previewsChat": true,
+	"modinfo": {"type": "FML", "modList": []},
+	"customProperty": {"a": 1},
+	"otherProperty": "value"
+}`
+
+func TestParseStatus(t *testing.T) {
+	status, err := ParseStatus([]byte(testStatusJSON))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	if status.Version.Name != "1.20.4" || status.Version.Protocol != 765 {
+		t.Errorf("Version: Expected {1.20.4 765} got %v.", status.Version)
+	}
+	if status.Players.Max != 20 || status.Players.Online != 2 || len(status.Players.Sample) != 1 {
+		t.Errorf("Players: Expected {20 2 [1 player]} got %v.", status.Players)
+	}
+	if status.Players.Sample[0].Name != "Notch" {
+		t.Errorf("Sample: Expected Notch got %v.", status.Players.Sample[0].Name)
+	}
+	if status.Description.PlainText() != "Hello world" {
+		t.Errorf("Description: Expected Hello world got %v.", status.Description.PlainText())
+	}
+	if status.Favicon != Favicon("data:image/png;base64,AAAA") {
+		t.Errorf("Favicon: Expected data:image/png;base64,AAAA got %v.", status.Favicon)
+	}
+	if !status.EnforcesSecureChat || !status.PreviewsChat {
+		t.Errorf("Chat: Expected true, true got %v, %v.", status.EnforcesSecureChat, status.PreviewsChat)
+	}
+	if status.ModInfo == nil || status.ModInfo.Type != "FML" {
+		t.Errorf("ModInfo: Expected FML got %v.", status.ModInfo)
+	}
+
+	expectedExtra := map[string]string{
+		"customProperty": `{"a": 1}`,
+		"otherProperty":  `"value"`,
+	}
+	if len(status.Extra) != len(expectedExtra) {
+		t.Errorf("Extra: Expected %d properties got %d.", len(expectedExtra), len(status.Extra))
+	}
+	for k, v := range expectedExtra {
+		if string(status.Extra[k]) != v {
+			t.Errorf("Extra %s: Expected %v got %v.", k, v, string(status.Extra[k]))
+		}
+	}
+}
+
+func TestStatusRoundTrip(t *testing.T) {
+	status, err := ParseStatus([]byte(testStatusJSON))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	var expected, res map[string]interface{}
+	json.Unmarshal([]byte(testStatusJSON), &expected)
+	json.Unmarshal(data, &res)
+
+	for k := range expected {
+		expectedValue, _ := json.Marshal(expected[k])
+		resValue, _ := json.Marshal(res[k])
+		if string(expectedValue) != string(resValue) {
+			t.Errorf("Property %s: Expected %s got %s.", k, expectedValue, resValue)
+		}
+	}
+}
+
+func TestParseStatusInvalid(t *testing.T) {
+	inputs := []string{
+		`{"version": {"name": "1.20.4", "protocol": "765"}}`,
+		`[]`,
+		`{`,
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		_, err := ParseStatus([]byte(inputs[i]))
+		if err == nil {
+			t.Errorf("Error %d: Expected true got false.", i)
+		}
+	}
+}
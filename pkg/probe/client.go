@@ -0,0 +1,281 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/bedrock"
+	"github.com/xrjr/mcutils/pkg/ping"
+	"github.com/xrjr/mcutils/pkg/status"
+)
+
+// Protocol is a protocol which can be used to probe a server.
+type Protocol string
+
+const (
+	ProtocolPing            Protocol = "ping"
+	ProtocolPingLegacy1_6_4 Protocol = "ping-legacy-1.6.4"
+	ProtocolPingLegacy      Protocol = "ping-legacy"
+	ProtocolBedrock         Protocol = "ping-bedrock"
+
+	DefaultJavaPort    int = 25565
+	DefaultBedrockPort int = 19132
+)
+
+var (
+	// DefaultProtocols is the default order in which protocols are tried : from the most recent java protocol to the oldest, then bedrock.
+	DefaultProtocols []Protocol = []Protocol{ProtocolPing, ProtocolPingLegacy1_6_4, ProtocolPingLegacy, ProtocolBedrock}
+
+	ErrNoProtocol       error = errors.New("no protocol to probe")
+	ErrUnknownProtocol  error = errors.New("unknown protocol")
+	ErrAllProtocolsFail error = errors.New("all protocols failed")
+)
+
+// Result is the result of a successful probe. Protocol is the protocol which answered, and only the field corresponding to it is set.
+// For the modern ping, Status is nil if the properties couldn't be decoded into it (e.g. a property has an unexpected type) : only Properties is set then.
+type Result struct {
+	Protocol Protocol `json:"protocol"`
+	Latency  int      `json:"latency"`
+
+	Status     *ping.Status             `json:"status,omitempty"`
+	Properties ping.JSON                `json:"properties,omitempty"`
+	Legacy     *ping.LegacyPingInfos    `json:"legacy,omitempty"`
+	Bedrock    *bedrock.UnconnectedPong `json:"bedrock,omitempty"`
+}
+
+// ServerStatus converts the result of the protocol which answered into a status.ServerStatus.
+func (r Result) ServerStatus() status.ServerStatus {
+	switch {
+	case r.Status != nil:
+		return status.FromStatus(*r.Status)
+	case r.Properties != nil:
+		return status.FromInfos(r.Properties.Infos())
+	case r.Legacy != nil:
+		return status.FromLegacyPingInfos(*r.Legacy)
+	case r.Bedrock != nil:
+		return status.FromUnconnectedPong(*r.Bedrock)
+	}
+
+	return status.ServerStatus{}
+}
+
+// Error is returned when no protocol succeeded. It contains the error returned by each tried protocol.
+type Error struct {
+	Protocols []Protocol
+	Errors    []error
+}
+
+// Error returns the errors of all protocols, in the order they were tried.
+func (e *Error) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString(ErrAllProtocolsFail.Error())
+	for i := range e.Protocols {
+		if i == 0 {
+			sb.WriteString(" (")
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(string(e.Protocols[i]))
+		sb.WriteString(": ")
+		sb.WriteString(e.Errors[i].Error())
+	}
+	if len(e.Protocols) > 0 {
+		sb.WriteString(")")
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns ErrAllProtocolsFail.
+func (e *Error) Unwrap() error {
+	return ErrAllProtocolsFail
+}
+
+// probeFunc probes a server using a single protocol.
+type probeFunc func(ctx context.Context, hostname string, port int) (Result, error)
+
+// probeFuncs are the implementations of each protocol.
+var probeFuncs map[Protocol]probeFunc = map[Protocol]probeFunc{
+	ProtocolPing: func(ctx context.Context, hostname string, port int) (Result, error) {
+		// The server speaks the modern protocol even if its properties don't fit the typed Status, so the raw properties are kept in this case.
+		properties, latency, err := ping.PingContext(ctx, hostname, port)
+		if err != nil {
+			return Result{}, err
+		}
+
+		res := Result{Latency: latency, Properties: properties}
+		raw, err := json.Marshal(properties)
+		if err == nil {
+			if pingStatus, err := ping.ParseStatus(raw); err == nil {
+				res.Status = &pingStatus
+			}
+		}
+		return res, nil
+	},
+	ProtocolPingLegacy1_6_4: func(ctx context.Context, hostname string, port int) (Result, error) {
+		infos, latency, err := ping.PingLegacy1_6_4Context(ctx, hostname, port)
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Latency: latency, Legacy: &infos}, nil
+	},
+	ProtocolPingLegacy: func(ctx context.Context, hostname string, port int) (Result, error) {
+		infos, latency, err := ping.PingLegacyContext(ctx, hostname, port)
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Latency: latency, Legacy: &infos}, nil
+	},
+	ProtocolBedrock: func(ctx context.Context, hostname string, port int) (Result, error) {
+		pong, latency, err := bedrock.PingContext(ctx, hostname, port)
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Latency: latency, Bedrock: &pong}, nil
+	},
+}
+
+// ProbeClient is the probe client.
+type ProbeClient struct {
+	hostname string
+	port     int
+
+	// options
+
+	// Protocols are the protocols to try, by order of preference. Defaults to DefaultProtocols.
+	Protocols []Protocol
+	// Parallel makes all protocols being tried at the same time. The result is still the one of the most preferred protocol which succeeded.
+	Parallel bool
+	// Timeout is the maximum duration of each protocol attempt.
+	Timeout time.Duration
+}
+
+// NewClient returns a well-formed *ProbeClient.
+// If port is 0, the default port of each protocol is used (25565 for java, 19132 for bedrock).
+func NewClient(hostname string, port int) *ProbeClient {
+	return &ProbeClient{
+		hostname: hostname,
+		port:     port,
+
+		Protocols: DefaultProtocols,
+		Parallel:  false,
+		Timeout:   5 * time.Second,
+	}
+}
+
+// Probe tries each protocol until one succeeds, and returns its result.
+// If all protocols fail, a *Error is returned.
+func (client *ProbeClient) Probe() (Result, error) {
+	return client.ProbeContext(context.Background())
+}
+
+// ProbeContext is the same as Probe, but the whole process is aborted as soon as ctx is done.
+func (client *ProbeClient) ProbeContext(ctx context.Context) (Result, error) {
+	if len(client.Protocols) == 0 {
+		return Result{}, ErrNoProtocol
+	}
+
+	for _, protocol := range client.Protocols {
+		if _, ok := probeFuncs[protocol]; !ok {
+			return Result{}, ErrUnknownProtocol
+		}
+	}
+
+	if client.Parallel {
+		return client.probeParallel(ctx)
+	}
+
+	return client.probeSequential(ctx)
+}
+
+// probeSequential tries protocols one after another.
+func (client *ProbeClient) probeSequential(ctx context.Context) (Result, error) {
+	probeErr := &Error{}
+
+	for _, protocol := range client.Protocols {
+		res, err := client.probe(ctx, protocol)
+		if err == nil {
+			return res, nil
+		}
+
+		if ctx.Err() != nil {
+			return Result{}, ctx.Err()
+		}
+
+		probeErr.Protocols = append(probeErr.Protocols, protocol)
+		probeErr.Errors = append(probeErr.Errors, err)
+	}
+
+	return Result{}, probeErr
+}
+
+// probeAttempt is the outcome of a protocol attempt.
+type probeAttempt struct {
+	res Result
+	err error
+}
+
+// probeParallel tries all protocols at the same time.
+// Results are awaited by order of preference, so that a less preferred protocol answering first doesn't win (e.g. modern servers also answer legacy pings).
+// Remaining attempts are aborted as soon as the result is known.
+func (client *ProbeClient) probeParallel(ctx context.Context) (Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	attempts := make([]chan probeAttempt, len(client.Protocols))
+	for i, protocol := range client.Protocols {
+		attempts[i] = make(chan probeAttempt, 1)
+
+		go func(protocol Protocol, attempt chan<- probeAttempt) {
+			res, err := client.probe(ctx, protocol)
+			attempt <- probeAttempt{res: res, err: err}
+		}(protocol, attempts[i])
+	}
+
+	probeErr := &Error{}
+
+	for i, protocol := range client.Protocols {
+		attempt := <-attempts[i]
+		if attempt.err == nil {
+			return attempt.res, nil
+		}
+
+		if ctx.Err() != nil {
+			return Result{}, ctx.Err()
+		}
+
+		probeErr.Protocols = append(probeErr.Protocols, protocol)
+		probeErr.Errors = append(probeErr.Errors, attempt.err)
+	}
+
+	return Result{}, probeErr
+}
+
+// probe tries a single protocol, within the configured timeout.
+func (client *ProbeClient) probe(ctx context.Context, protocol Protocol) (Result, error) {
+	if client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+	}
+
+	port := client.port
+	if port == 0 {
+		port = DefaultJavaPort
+		if protocol == ProtocolBedrock {
+			port = DefaultBedrockPort
+		}
+	}
+
+	res, err := probeFuncs[protocol](ctx, client.hostname, port)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res.Protocol = protocol
+	return res, nil
+}
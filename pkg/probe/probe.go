@@ -0,0 +1,16 @@
+// probe package finds out which protocol a minecraft server speaks, by trying the java (modern and legacy) and bedrock pings in turn.
+package probe
+
+import "context"
+
+// Probe tries the default protocols one after another (modern ping, 1.6 legacy ping, legacy ping, then bedrock ping), and returns the result of the first one which succeeds.
+// If port is 0, the default port of each protocol is used.
+// If all protocols fail, an empty result and a *Error are returned.
+func Probe(hostname string, port int) (Result, error) {
+	return ProbeContext(context.Background(), hostname, port)
+}
+
+// ProbeContext is the same as Probe, but the whole process is aborted as soon as ctx is done.
+func ProbeContext(ctx context.Context, hostname string, port int) (Result, error) {
+	return NewClient(hostname, port).ProbeContext(ctx)
+}
@@ -0,0 +1,199 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/ping"
+)
+
+var errTestRefused error = errors.New("refused")
+
+// fakeProbeFuncs replaces probeFuncs for the duration of the test.
+// Protocols with a negative delay fail, others succeed after the delay, with the port used as latency.
+func fakeProbeFuncs(t *testing.T, delays map[Protocol]time.Duration) {
+	original := probeFuncs
+	t.Cleanup(func() {
+		probeFuncs = original
+	})
+
+	probeFuncs = make(map[Protocol]probeFunc)
+	for protocol, delay := range delays {
+		delay := delay
+		probeFuncs[protocol] = func(ctx context.Context, hostname string, port int) (Result, error) {
+			if delay < 0 {
+				return Result{}, errTestRefused
+			}
+
+			select {
+			case <-time.After(delay):
+				return Result{Latency: port}, nil
+			case <-ctx.Done():
+				return Result{}, ctx.Err()
+			}
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	fakeProbeFuncs(t, map[Protocol]time.Duration{
+		ProtocolPing:            -1,
+		ProtocolPingLegacy1_6_4: -1,
+		ProtocolPingLegacy:      -1,
+		ProtocolBedrock:         0,
+	})
+
+	res, err := Probe("localhost", 0)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if res.Protocol != ProtocolBedrock || res.Latency != DefaultBedrockPort {
+		t.Errorf("Expected %v on port %d got %v on port %d.", ProtocolBedrock, DefaultBedrockPort, res.Protocol, res.Latency)
+	}
+}
+
+func TestProbeParallel(t *testing.T) {
+	fakeProbeFuncs(t, map[Protocol]time.Duration{
+		ProtocolPing:            50 * time.Millisecond,
+		ProtocolPingLegacy1_6_4: 0,
+		ProtocolPingLegacy:      0,
+		ProtocolBedrock:         -1,
+	})
+
+	client := NewClient("localhost", 25566)
+	client.Parallel = true
+
+	res, err := client.Probe()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if res.Protocol != ProtocolPing || res.Latency != 25566 {
+		t.Errorf("Expected %v on port %d got %v on port %d.", ProtocolPing, 25566, res.Protocol, res.Latency)
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	fakeProbeFuncs(t, map[Protocol]time.Duration{
+		ProtocolPing:    time.Minute,
+		ProtocolBedrock: 0,
+	})
+
+	client := NewClient("localhost", 0)
+	client.Protocols = []Protocol{ProtocolPing, ProtocolBedrock}
+	client.Timeout = 10 * time.Millisecond
+
+	res, err := client.Probe()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if res.Protocol != ProtocolBedrock {
+		t.Errorf("Expected %v got %v.", ProtocolBedrock, res.Protocol)
+	}
+}
+
+func TestProbeError(t *testing.T) {
+	fakeProbeFuncs(t, map[Protocol]time.Duration{
+		ProtocolPing:            -1,
+		ProtocolPingLegacy1_6_4: -1,
+		ProtocolPingLegacy:      -1,
+		ProtocolBedrock:         -1,
+	})
+
+	for _, parallel := range []bool{false, true} {
+		client := NewClient("localhost", 0)
+		client.Parallel = parallel
+
+		_, err := client.Probe()
+		if !errors.Is(err, ErrAllProtocolsFail) {
+			t.Fatalf("Parallel %v: Expected %v got %v.", parallel, ErrAllProtocolsFail, err)
+		}
+
+		var probeErr *Error
+		if !errors.As(err, &probeErr) || len(probeErr.Errors) != len(DefaultProtocols) {
+			t.Errorf("Parallel %v: Expected %d errors got %v.", parallel, len(DefaultProtocols), err)
+		}
+	}
+
+	client := NewClient("localhost", 0)
+	client.Protocols = []Protocol{"unknown"}
+
+	_, err := client.Probe()
+	if err != ErrUnknownProtocol {
+		t.Errorf("Expected %v got %v.", ErrUnknownProtocol, err)
+	}
+}
+
+func TestProbeClosesConnections(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer l.Close()
+
+	// Connections are accepted, but never answered.
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+
+	client := NewClient("127.0.0.1", l.Addr().(*net.TCPAddr).Port)
+	client.Protocols = []Protocol{ProtocolPing, ProtocolPingLegacy}
+	client.Parallel = true
+	client.Timeout = 100 * time.Millisecond
+
+	_, err = client.Probe()
+	if err == nil {
+		t.Fatalf("Expected error got <nil>.")
+	}
+
+	// Each attempt must have closed its connection when it failed.
+	for i := 0; i < 2; i++ {
+		c := <-conns
+		defer c.Close()
+
+		c.SetReadDeadline(time.Now().Add(time.Second))
+		_, err = io.Copy(io.Discard, c)
+		if err != nil {
+			t.Errorf("Value %d: Expected <nil> got %v.", i, err)
+		}
+	}
+}
+
+func TestProbeUntypedStatus(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	// The protocol version is a string, which doesn't fit the typed Status.
+	server := ping.NewServer("", ping.Status{})
+	server.RawStatus = []byte(`{"version":{"name":"1.20.4","protocol":"765"},"players":{"online":1,"max":20},"description":"Lobby"}`)
+	go server.Serve(l)
+	defer server.Close()
+
+	client := NewClient("127.0.0.1", l.Addr().(*net.TCPAddr).Port)
+	client.Protocols = []Protocol{ProtocolPing, ProtocolPingLegacy}
+
+	res, err := client.Probe()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if res.Protocol != ProtocolPing || res.Status != nil || res.Properties == nil {
+		t.Errorf("Expected %v with properties only got %v.", ProtocolPing, res)
+	}
+
+	ss := res.ServerStatus()
+	if ss.Version != "1.20.4" || ss.MaxPlayers != 20 {
+		t.Errorf("Expected 1.20.4, 20 got %s, %d.", ss.Version, ss.MaxPlayers)
+	}
+}
@@ -0,0 +1,143 @@
+// protocol package maps Minecraft protocol version numbers to the releases using them, for Java Edition (netty and pre-netty protocols) and Bedrock Edition.
+package protocol
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// JavaSnapshotBit is set in the protocol version of Java Edition snapshots and pre-releases since 1.16.4-pre1.
+	JavaSnapshotBit int = 0x40000000
+)
+
+// Version is a release of the game, with the protocol version it uses.
+type Version struct {
+	Name     string `json:"name"`
+	Protocol int    `json:"protocol"`
+}
+
+// JavaVersions are the Java Edition releases using the netty protocol (1.7.2+), from oldest to newest.
+// This is the protocol version sent in the handshake, and returned by the server list ping.
+var JavaVersions = []Version{
+	{"1.7.2", 4}, {"1.7.4", 4}, {"1.7.5", 4},
+	{"1.7.6", 5}, {"1.7.7", 5}, {"1.7.8", 5}, {"1.7.9", 5}, {"1.7.10", 5},
+	{"1.8", 47}, {"1.8.1", 47}, {"1.8.2", 47}, {"1.8.3", 47}, {"1.8.4", 47}, {"1.8.5", 47}, {"1.8.6", 47}, {"1.8.7", 47}, {"1.8.8", 47}, {"1.8.9", 47},
+	{"1.9", 107}, {"1.9.1", 108}, {"1.9.2", 109}, {"1.9.3", 110}, {"1.9.4", 110},
+	{"1.10", 210}, {"1.10.1", 210}, {"1.10.2", 210},
+	{"1.11", 315}, {"1.11.1", 316}, {"1.11.2", 316},
+	{"1.12", 335}, {"1.12.1", 338}, {"1.12.2", 340},
+	{"1.13", 393}, {"1.13.1", 401}, {"1.13.2", 404},
+	{"1.14", 477}, {"1.14.1", 480}, {"1.14.2", 485}, {"1.14.3", 490}, {"1.14.4", 498},
+	{"1.15", 573}, {"1.15.1", 575}, {"1.15.2", 578},
+	{"1.16", 735}, {"1.16.1", 736}, {"1.16.2", 751}, {"1.16.3", 753}, {"1.16.4", 754}, {"1.16.5", 754},
+	{"1.17", 755}, {"1.17.1", 756},
+	{"1.18", 757}, {"1.18.1", 757}, {"1.18.2", 758},
+	{"1.19", 759}, {"1.19.1", 760}, {"1.19.2", 760}, {"1.19.3", 761}, {"1.19.4", 762},
+	{"1.20", 763}, {"1.20.1", 763}, {"1.20.2", 764}, {"1.20.3", 765}, {"1.20.4", 765}, {"1.20.5", 766}, {"1.20.6", 766},
+	{"1.21", 767}, {"1.21.1", 767}, {"1.21.2", 768}, {"1.21.3", 768}, {"1.21.4", 769}, {"1.21.5", 770}, {"1.21.6", 771},
+	{"1.21.7", 772}, {"1.21.8", 772}, {"1.21.9", 773}, {"1.21.10", 773},
+}
+
+// JavaLegacyVersions are the Java Edition releases using the pre-netty protocol, from oldest to newest.
+// This is the protocol version returned by the legacy server list ping (1.4+ servers).
+var JavaLegacyVersions = []Version{
+	{"1.3.1", 39}, {"1.3.2", 39},
+	{"1.4.2", 47}, {"1.4.4", 49}, {"1.4.5", 49}, {"1.4.6", 51}, {"1.4.7", 51},
+	{"1.5", 60}, {"1.5.1", 60}, {"1.5.2", 61},
+	{"1.6", 72}, {"1.6.1", 73}, {"1.6.2", 74}, {"1.6.3", 77}, {"1.6.4", 78},
+}
+
+// BedrockVersions are the Bedrock Edition releases, from oldest to newest.
+// This is the protocol version returned by the bedrock unconnected ping.
+var BedrockVersions = []Version{
+	{"1.16.100", 419}, {"1.16.200", 422}, {"1.16.210", 428}, {"1.16.220", 431},
+	{"1.17.0", 440}, {"1.17.10", 448}, {"1.17.30", 465}, {"1.17.40", 471},
+	{"1.18.0", 475}, {"1.18.10", 486}, {"1.18.30", 503},
+	{"1.19.0", 527}, {"1.19.10", 534}, {"1.19.20", 544}, {"1.19.21", 545}, {"1.19.30", 554}, {"1.19.40", 557}, {"1.19.50", 560},
+	{"1.19.60", 567}, {"1.19.63", 568}, {"1.19.70", 575}, {"1.19.80", 582},
+	{"1.20.0", 589}, {"1.20.10", 594}, {"1.20.30", 618}, {"1.20.40", 622}, {"1.20.50", 630}, {"1.20.60", 649}, {"1.20.70", 662}, {"1.20.80", 671},
+	{"1.21.0", 685}, {"1.21.2", 686}, {"1.21.20", 712}, {"1.21.30", 729}, {"1.21.40", 748}, {"1.21.50", 766}, {"1.21.60", 776},
+	{"1.21.70", 786}, {"1.21.80", 800}, {"1.21.90", 818},
+}
+
+// snapshotNameRegexp matches weekly snapshots (e.g. 23w45a), pre-releases and release candidates.
+var snapshotNameRegexp = regexp.MustCompile(`^\d{2}w\d{2}[a-z~]$|-pre\d*$|-rc\d*$| Pre-Release \d+$| Release Candidate \d+$|-snapshot-\d+$`)
+
+// releases returns the names of the versions using the given protocol.
+func releases(versions []Version, protocol int) []string {
+	var names []string
+
+	for _, version := range versions {
+		if version.Protocol == protocol {
+			names = append(names, version.Name)
+		}
+	}
+
+	return names
+}
+
+// protocolOf returns the protocol used by the named version.
+func protocolOf(versions []Version, name string) (int, bool) {
+	name = strings.TrimSpace(name)
+
+	for _, version := range versions {
+		if version.Name == name {
+			return version.Protocol, true
+		}
+	}
+
+	return 0, false
+}
+
+// JavaReleases returns the Java Edition releases using the given netty protocol version, from oldest to newest.
+// It returns nil if the protocol version is unknown, or is a snapshot protocol version.
+func JavaReleases(protocol int) []string {
+	return releases(JavaVersions, protocol)
+}
+
+// JavaProtocol returns the netty protocol version used by the given Java Edition release.
+func JavaProtocol(release string) (int, bool) {
+	return protocolOf(JavaVersions, release)
+}
+
+// JavaLegacyReleases returns the Java Edition releases using the given pre-netty protocol version, from oldest to newest.
+func JavaLegacyReleases(protocol int) []string {
+	return releases(JavaLegacyVersions, protocol)
+}
+
+// JavaLegacyProtocol returns the pre-netty protocol version used by the given Java Edition release.
+func JavaLegacyProtocol(release string) (int, bool) {
+	return protocolOf(JavaLegacyVersions, release)
+}
+
+// BedrockReleases returns the Bedrock Edition releases using the given protocol version, from oldest to newest.
+func BedrockReleases(protocol int) []string {
+	return releases(BedrockVersions, protocol)
+}
+
+// BedrockProtocol returns the protocol version used by the given Bedrock Edition release.
+func BedrockProtocol(release string) (int, bool) {
+	return protocolOf(BedrockVersions, release)
+}
+
+// LatestJava returns the newest known Java Edition release.
+func LatestJava() Version {
+	return JavaVersions[len(JavaVersions)-1]
+}
+
+// LatestBedrock returns the newest known Bedrock Edition release.
+func LatestBedrock() Version {
+	return BedrockVersions[len(BedrockVersions)-1]
+}
+
+// IsJavaSnapshot returns true if the netty protocol version is the one of a snapshot, pre-release or release candidate.
+// Only snapshots since 1.16.4-pre1 can be detected, as older ones used the same numbering as releases.
+func IsJavaSnapshot(protocol int) bool {
+	return protocol > 0 && protocol&JavaSnapshotBit != 0
+}
+
+// IsSnapshotName returns true if the version name is the one of a snapshot, pre-release or release candidate (e.g. 23w45a, 1.20.5-pre1, 1.20-rc1).
+func IsSnapshotName(name string) bool {
+	return snapshotNameRegexp.MatchString(strings.TrimSpace(name))
+}
@@ -0,0 +1,80 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestJavaReleases(t *testing.T) {
+	inputs := []int{4, 340, 765, 0, 0x40000000 | 180}
+	expectedValues := [][]string{
+		{"1.7.2", "1.7.4", "1.7.5"},
+		{"1.12.2"},
+		{"1.20.3", "1.20.4"},
+		nil,
+		nil,
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		res := JavaReleases(inputs[i])
+
+		if len(res) != len(expectedValues[i]) {
+			t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], res)
+			continue
+		}
+		for j := range res {
+			if res[j] != expectedValues[i][j] {
+				t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], res)
+				break
+			}
+		}
+	}
+}
+
+func TestProtocol(t *testing.T) {
+	inputs := []string{"1.8.9", "1.20.4", " 1.21.1 ", "1.6.4", "1.20.80", "unknown"}
+	lookups := []func(string) (int, bool){JavaProtocol, JavaProtocol, JavaProtocol, JavaLegacyProtocol, BedrockProtocol, JavaProtocol}
+	expectedValues := []int{47, 765, 767, 78, 671, 0}
+	expectedOks := []bool{true, true, true, true, true, false}
+
+	for i := 0; i < len(inputs); i++ {
+		res, ok := lookups[i](inputs[i])
+
+		if res != expectedValues[i] || ok != expectedOks[i] {
+			t.Errorf("Value %d: Expected %v, %v got %v, %v.", i, expectedValues[i], expectedOks[i], res, ok)
+		}
+	}
+}
+
+func TestVersionsOrder(t *testing.T) {
+	tables := [][]Version{JavaVersions, JavaLegacyVersions, BedrockVersions}
+
+	for i, table := range tables {
+		for j := 1; j < len(table); j++ {
+			if table[j].Protocol < table[j-1].Protocol {
+				t.Errorf("Table %d: %v is before %v.", i, table[j-1], table[j])
+			}
+		}
+	}
+}
+
+func TestIsJavaSnapshot(t *testing.T) {
+	inputs := []int{-1, 0, 765, 0x40000000 | 1, 0x40000000 | 180}
+	expectedValues := []bool{false, false, false, true, true}
+
+	for i := 0; i < len(inputs); i++ {
+		if res := IsJavaSnapshot(inputs[i]); res != expectedValues[i] {
+			t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], res)
+		}
+	}
+}
+
+func TestIsSnapshotName(t *testing.T) {
+	inputs := []string{"1.20.4", "23w45a", "1.20.5-pre1", "1.20-rc1", "1.14 Pre-Release 5", "Paper 1.20.4", "1.21.10"}
+	expectedValues := []bool{false, true, true, true, true, false, false}
+
+	for i := 0; i < len(inputs); i++ {
+		if res := IsSnapshotName(inputs[i]); res != expectedValues[i] {
+			t.Errorf("Value %d: Expected %v got %v.", i, expectedValues[i], res)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package query
 
 import (
+	"context"
+	"errors"
 	"math/rand"
 	"net"
 	"strconv"
@@ -14,6 +16,9 @@ const (
 )
 
 var (
+	ErrUnexpectedPacketType error = errors.New("unexpected packet type")
+	ErrSessionIDMismatch    error = errors.New("session id doesn't match the one of the client")
+
 	FullStatRequestPadding   = [4]byte{0x00, 0x00, 0x00, 0x00}
 	FullStatResponsePadding1 = [11]byte{0x73, 0x70, 0x6C, 0x69, 0x74, 0x6E, 0x75, 0x6D, 0x00, 0x80, 0x00}
 	FullStatResponsePadding2 = [10]byte{0x01, 0x70, 0x6C, 0x61, 0x79, 0x65, 0x72, 0x5F, 0x00, 0x00}
@@ -25,6 +30,15 @@ func generateSessionID() uint32 {
 	return uint32(res) & 0x0F0F0F0F
 }
 
+// nextSessionID returns a random valid session id, different from sessionID.
+func nextSessionID(sessionID uint32) uint32 {
+	next := generateSessionID()
+	for next == sessionID {
+		next = generateSessionID()
+	}
+	return next
+}
+
 // generateHandshakeRequest generates a networking.Output corresponding to a handshake request.
 func generateHandshakeRequest(sessionID uint32) networking.Output {
 	out := networking.NewOutput()
@@ -140,7 +154,7 @@ func parseBasicStatResponse(in networking.Input) (*basicStatResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	bsRes.HostPort = int16(hostport)
+	bsRes.HostPort = hostport
 
 	hostip, err := in.ReadNullTerminatedString()
 	if err != nil {
@@ -233,18 +247,25 @@ func parseFullStatResponse(in networking.Input) (*fullStatResponse, error) {
 }
 
 // QueryClient is the query client.
-// challengeToken isn't stored in the client as it can change in the lifetime of a client, while session ID doesn't.
+// challengeToken isn't stored in the client as it can change in the lifetime of a client, while session ID only changes when a handshake is retransmitted.
 type QueryClient struct {
 	hostname  string
 	port      int
 	conn      *networking.UDPConn
 	sessionID uint32
+	attempts  int
 
 	// options
 	SkipSRVLookup                bool
 	ForceUDPProtocolForSRVLookup bool
+	DirectFallback               networking.DirectFallback
 	DialTimeout                  time.Duration
-	ReadTimeout                  time.Duration
+	// ReadTimeout limits each request, including its retransmissions, which are defined by Retry.
+	ReadTimeout time.Duration
+	Retry       networking.RetryPolicy
+	// Resolver is used for the SRV lookup, and Dialer to open the connection. They default to the ones of the net package.
+	Resolver networking.Resolver
+	Dialer   networking.Dialer
 }
 
 // NewClient returns a well-formed *QueryClient.
@@ -263,19 +284,28 @@ func NewClient(hostname string, port int) *QueryClient {
 		ForceUDPProtocolForSRVLookup: false,
 		DialTimeout:                  5 * time.Second,
 		ReadTimeout:                  5 * time.Second,
+		Retry:                        networking.DefaultRetryPolicy,
 	}
 }
 
 // Connect establishes a connection via UDP.
 func (client *QueryClient) Connect() error {
+	return client.ConnectContext(context.Background())
+}
+
+// ConnectContext is the same as Connect, but the connection attempt is aborted as soon as ctx is done.
+func (client *QueryClient) ConnectContext(ctx context.Context) error {
 	if client.conn != nil {
 		return networking.ErrConnectionAlreadyEstablished
 	}
 
-	conn, err := networking.DialUDP(client.hostname, client.port, networking.DialUDPOptions{
+	conn, err := networking.DialUDPContext(ctx, client.hostname, client.port, networking.DialUDPOptions{
 		SkipSRVLookup:                client.SkipSRVLookup,
 		ForceUDPProtocolForSRVLookup: client.ForceUDPProtocolForSRVLookup,
+		DirectFallback:               client.DirectFallback,
 		DialTimeout:                  client.DialTimeout,
+		Resolver:                     client.Resolver,
+		Dialer:                       client.Dialer,
 	})
 	if err != nil {
 		return err
@@ -288,51 +318,76 @@ func (client *QueryClient) Connect() error {
 
 // Handshake sends a handshake query to the server, and returns the challenge token if successful.
 func (client *QueryClient) Handshake() (uint32, error) {
+	return client.HandshakeContext(context.Background())
+}
+
+// HandshakeContext is the same as Handshake, but the request is aborted as soon as ctx is done.
+func (client *QueryClient) HandshakeContext(ctx context.Context) (uint32, error) {
 	if client.conn == nil {
 		return 0, networking.ErrConnectionNotEstablished
 	}
 
-	hsRequest := generateHandshakeRequest(client.sessionID)
-
-	// UDPConn reads are made in send method
-	err := client.conn.SetReadDeadline(client.ReadTimeout)
-	if err != nil {
-		return 0, err
-	}
-
-	hsResponse, err := client.conn.Send(hsRequest)
-	if err != nil {
-		return 0, err
+	// The server issues a new challenge token for each handshake it receives, so a late reply to a previous attempt may hold a stale token.
+	// Each retransmission thus has its own session ID, and only the reply to the last attempt is accepted.
+	sessionID := client.sessionID
+	attempt := 0
+	request := func() networking.Output {
+		attempt++
+		if attempt > 1 {
+			sessionID = nextSessionID(sessionID)
+		}
+		return generateHandshakeRequest(sessionID)
 	}
 
-	hs, err := parseHandshakeResponse(hsResponse)
+	var hs *handshakeResponse
+	err := client.exchange(ctx, request, func(in networking.Input) error {
+		res, err := parseHandshakeResponse(in)
+		if err != nil {
+			return err
+		}
+		err = checkResponse(res.Type, res.SessionID, HandshakeType, sessionID)
+		if err != nil {
+			return err
+		}
+		hs = res
+		return nil
+	})
 	if err != nil {
 		return 0, err
 	}
 
+	client.sessionID = sessionID
 	return hs.ChallengeToken, nil
 }
 
 // BasicStat sends a basic stat query to the server, and returns the formatted result.
 func (client *QueryClient) BasicStat(challengeToken uint32) (BasicStat, error) {
+	return client.BasicStatContext(context.Background(), challengeToken)
+}
+
+// BasicStatContext is the same as BasicStat, but the request is aborted as soon as ctx is done.
+func (client *QueryClient) BasicStatContext(ctx context.Context, challengeToken uint32) (BasicStat, error) {
 	if client.conn == nil {
 		return BasicStat{}, networking.ErrConnectionNotEstablished
 	}
 
 	bsRequest := generateBasicStatRequest(client.sessionID, challengeToken)
 
-	// UDPConn reads are made in send method
-	err := client.conn.SetReadDeadline(client.ReadTimeout)
-	if err != nil {
-		return BasicStat{}, err
-	}
-
-	bsResponse, err := client.conn.Send(bsRequest)
-	if err != nil {
-		return BasicStat{}, err
-	}
-
-	bs, err := parseBasicStatResponse(bsResponse)
+	var bs *basicStatResponse
+	err := client.exchange(ctx, func() networking.Output {
+		return bsRequest
+	}, func(in networking.Input) error {
+		res, err := parseBasicStatResponse(in)
+		if err != nil {
+			return err
+		}
+		err = checkResponse(res.Type, res.SessionID, StatType, client.sessionID)
+		if err != nil {
+			return err
+		}
+		bs = res
+		return nil
+	})
 	if err != nil {
 		return BasicStat{}, err
 	}
@@ -342,29 +397,61 @@ func (client *QueryClient) BasicStat(challengeToken uint32) (BasicStat, error) {
 
 // FullStat sends a full stat query to the server, and returns the formatted result.
 func (client *QueryClient) FullStat(challengeToken uint32) (FullStat, error) {
+	return client.FullStatContext(context.Background(), challengeToken)
+}
+
+// FullStatContext is the same as FullStat, but the request is aborted as soon as ctx is done.
+func (client *QueryClient) FullStatContext(ctx context.Context, challengeToken uint32) (FullStat, error) {
 	if client.conn == nil {
 		return FullStat{}, networking.ErrConnectionNotEstablished
 	}
 
 	fsRequest := generateFullStatRequest(client.sessionID, challengeToken)
 
-	// UDPConn reads are made in send method
-	err := client.conn.SetReadDeadline(client.ReadTimeout)
+	var fs *fullStatResponse
+	err := client.exchange(ctx, func() networking.Output {
+		return fsRequest
+	}, func(in networking.Input) error {
+		res, err := parseFullStatResponse(in)
+		if err != nil {
+			return err
+		}
+		err = checkResponse(res.Type, res.SessionID, StatType, client.sessionID)
+		if err != nil {
+			return err
+		}
+		fs = res
+		return nil
+	})
 	if err != nil {
 		return FullStat{}, err
 	}
 
-	fsResponse, err := client.conn.Send(fsRequest)
-	if err != nil {
-		return FullStat{}, err
-	}
+	return fs.fullStat(), nil
+}
 
-	fs, err := parseFullStatResponse(fsResponse)
-	if err != nil {
-		return FullStat{}, err
+// exchange sends the request returned by request, and passes the responses to handle until one is accepted, retransmitting it as defined by the Retry option.
+// Responses rejected by handle (stale ones, or ones for another session) are discarded.
+func (client *QueryClient) exchange(ctx context.Context, request func() networking.Output, handle func(networking.Input) error) error {
+	attempts, err := client.conn.ExchangeContext(ctx, request, client.ReadTimeout, client.Retry, handle)
+	client.attempts = attempts
+	return err
+}
+
+// checkResponse checks that a response has the expected type and session ID.
+func checkResponse(type_ byte, sessionID uint32, expectedType byte, expectedSessionID uint32) error {
+	if type_ != expectedType {
+		return ErrUnexpectedPacketType
 	}
+	if sessionID != expectedSessionID {
+		return ErrSessionIDMismatch
+	}
+	return nil
+}
 
-	return fs.fullStat(), nil
+// LastAttempts returns the number of times the last request has been sent, 1 meaning it hasn't been retransmitted.
+func (client *QueryClient) LastAttempts() int {
+	return client.attempts
 }
 
 // Disconnect closes the connection.
@@ -378,3 +465,12 @@ func (client *QueryClient) Disconnect() error {
 	client.conn = nil
 	return err
 }
+
+// Target returns the address the client is connected to, which differs from its hostname and port if it comes from a SRV record.
+func (client *QueryClient) Target() (networking.Target, error) {
+	if client.conn == nil {
+		return networking.Target{}, networking.ErrConnectionNotEstablished
+	}
+
+	return client.conn.Target(), nil
+}
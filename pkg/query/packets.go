@@ -1,5 +1,7 @@
 package query
 
+import "github.com/xrjr/mcutils/pkg/chat"
+
 // packet is the common structure conatained in all query datagrams.
 type packet struct {
 	Type      byte
@@ -20,7 +22,7 @@ type basicStatResponse struct {
 	Map        string
 	NumPlayers int
 	MaxPlayers int
-	HostPort   int16
+	HostPort   uint16
 	HostIP     string
 }
 
@@ -65,8 +67,20 @@ type BasicStat struct {
 	HostIP     string `json:"hostIp"`
 }
 
+// MOTDComponent converts the MOTD, which may contain legacy formatting codes, into a text component.
+func (bs BasicStat) MOTDComponent() chat.Component {
+	return chat.FromLegacy(bs.MOTD)
+}
+
 // FullStat contains full stat query informations.
 type FullStat struct {
 	Properties    map[string]string `json:"properties"`
 	OnlinePlayers []string          `json:"onlinePlayers"`
 }
+
+// request is the type respresenting a query request, as received by the server.
+type request struct {
+	packet
+	ChallengeToken uint32
+	Full           bool
+}
@@ -2,27 +2,30 @@
 // This package is strictly compliant with the following documentation : https://minecraft.wiki/w/Query.
 package query
 
+import "context"
+
 // QueryBasic returns the basic stat of a minecraft server.
 // If an error occured at any point of the process, an empty BasicStat and a non nil error are returned.
 func QueryBasic(hostname string, port int) (BasicStat, error) {
-	client := NewClient(hostname, port)
+	return QueryBasicContext(context.Background(), hostname, port)
+}
 
-	err := client.Connect()
-	if err != nil {
-		return BasicStat{}, err
-	}
+// QueryBasicContext is the same as QueryBasic, but the whole process is aborted as soon as ctx is done.
+func QueryBasicContext(ctx context.Context, hostname string, port int) (BasicStat, error) {
+	client := NewClient(hostname, port)
 
-	token, err := client.Handshake()
+	err := client.ConnectContext(ctx)
 	if err != nil {
 		return BasicStat{}, err
 	}
+	defer client.Disconnect()
 
-	basicStat, err := client.BasicStat(token)
+	token, err := client.HandshakeContext(ctx)
 	if err != nil {
 		return BasicStat{}, err
 	}
 
-	err = client.Disconnect()
+	basicStat, err := client.BasicStatContext(ctx, token)
 	if err != nil {
 		return BasicStat{}, err
 	}
@@ -33,24 +36,25 @@ func QueryBasic(hostname string, port int) (BasicStat, error) {
 // QueryFull returns the full stat of a minecraft server.
 // If an error occured at any point of the process, an empty FullStat and a non nil error are returned.
 func QueryFull(hostname string, port int) (FullStat, error) {
-	client := NewClient(hostname, port)
+	return QueryFullContext(context.Background(), hostname, port)
+}
 
-	err := client.Connect()
-	if err != nil {
-		return FullStat{}, err
-	}
+// QueryFullContext is the same as QueryFull, but the whole process is aborted as soon as ctx is done.
+func QueryFullContext(ctx context.Context, hostname string, port int) (FullStat, error) {
+	client := NewClient(hostname, port)
 
-	token, err := client.Handshake()
+	err := client.ConnectContext(ctx)
 	if err != nil {
 		return FullStat{}, err
 	}
+	defer client.Disconnect()
 
-	fullStat, err := client.FullStat(token)
+	token, err := client.HandshakeContext(ctx)
 	if err != nil {
 		return FullStat{}, err
 	}
 
-	err = client.Disconnect()
+	fullStat, err := client.FullStatContext(ctx, token)
 	if err != nil {
 		return FullStat{}, err
 	}
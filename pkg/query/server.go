@@ -0,0 +1,418 @@
+package query
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+const (
+	DefaultServerAddr      string        = ":25565"
+	DefaultChallengeExpiry time.Duration = 30 * time.Second
+	HandshakeType          byte          = 9
+	StatType               byte          = 0
+)
+
+var (
+	ErrServerClosed error = errors.New("query server is closed")
+	ErrInvalidMagic error = errors.New("invalid magic value")
+)
+
+// fullStatKeys are the keys of the full stat properties, in the order they are sent by vanilla servers. Other properties are sent after them.
+var fullStatKeys = []string{"hostname", "gametype", "game_id", "version", "plugins", "map", "numplayers", "maxplayers", "hostport", "hostip"}
+
+// generateChallengeToken generates a cryptographically secure random challenge token, which fits in an int32 as clients expect.
+func generateChallengeToken() (uint32, error) {
+	var buf [4]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(buf[:]) & 0x7FFFFFFF, nil
+}
+
+// parseRequest parses a request datagram into a *request.
+func parseRequest(in networking.Input) (*request, error) {
+	var req *request = &request{}
+
+	magic, err := in.ReadBigEndianInt16()
+	if err != nil {
+		return nil, err
+	}
+	if magic != MagicValue {
+		return nil, ErrInvalidMagic
+	}
+
+	type_, err := in.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	req.Type = type_
+
+	sessionID, err := in.ReadBigEndianInt32()
+	if err != nil {
+		return nil, err
+	}
+	req.SessionID = sessionID
+
+	if req.Type != StatType {
+		return req, nil
+	}
+
+	challengeToken, err := in.ReadBigEndianInt32()
+	if err != nil {
+		return nil, err
+	}
+	req.ChallengeToken = challengeToken
+
+	// Full stat requests are padded with 4 bytes.
+	_, err = in.ReadBytes(len(FullStatRequestPadding))
+	req.Full = err == nil
+
+	return req, nil
+}
+
+// generateHandshakeResponse generates a networking.Output corresponding to a handshake response.
+func generateHandshakeResponse(sessionID uint32, challengeToken uint32) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteSingleByte(HandshakeType)
+
+	out.WriteBigEndianInt32(sessionID)
+
+	out.WriteNullTerminatedString(strconv.FormatInt(int64(int32(challengeToken)), 10))
+
+	return out
+}
+
+// generateBasicStatResponse generates a networking.Output corresponding to a basic stat response.
+func generateBasicStatResponse(sessionID uint32, bs BasicStat) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteSingleByte(StatType)
+
+	out.WriteBigEndianInt32(sessionID)
+
+	out.WriteNullTerminatedString(bs.MOTD)
+
+	out.WriteNullTerminatedString(bs.GameType)
+
+	out.WriteNullTerminatedString(bs.Map)
+
+	out.WriteNullTerminatedString(strconv.Itoa(bs.NumPlayers))
+
+	out.WriteNullTerminatedString(strconv.Itoa(bs.MaxPlayers))
+
+	out.WriteLittleEndianInt16(uint16(bs.HostPort))
+
+	out.WriteNullTerminatedString(bs.HostIP)
+
+	return out
+}
+
+// generateFullStatResponse generates a networking.Output corresponding to a full stat response.
+func generateFullStatResponse(sessionID uint32, fs FullStat) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteSingleByte(StatType)
+
+	out.WriteBigEndianInt32(sessionID)
+
+	out.WriteBytes(FullStatResponsePadding1[:])
+
+	for _, key := range sortedPropertyKeys(fs.Properties) {
+		out.WriteNullTerminatedString(key)
+		out.WriteNullTerminatedString(fs.Properties[key])
+	}
+	out.WriteNullTerminatedString("")
+
+	out.WriteBytes(FullStatResponsePadding2[:])
+
+	for _, player := range fs.OnlinePlayers {
+		out.WriteNullTerminatedString(player)
+	}
+	out.WriteNullTerminatedString("")
+
+	return out
+}
+
+// sortedPropertyKeys returns the keys of properties : the vanilla ones first, in the vanilla order, then the others, sorted.
+func sortedPropertyKeys(properties map[string]string) []string {
+	keys := make([]string, 0, len(properties))
+	known := make(map[string]bool, len(fullStatKeys))
+
+	for _, key := range fullStatKeys {
+		known[key] = true
+		if _, ok := properties[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	var others []string
+	for key := range properties {
+		if !known[key] && key != "" {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+
+	return append(keys, others...)
+}
+
+// challenge is a challenge token issued to an address.
+type challenge struct {
+	token   uint32
+	created time.Time
+}
+
+// Server is a query server, which behaves like the vanilla one : challenge tokens are issued per address and expire, and requests with an invalid token are ignored.
+type Server struct {
+	mutex      sync.Mutex
+	conn       net.PacketConn
+	closed     bool
+	challenges map[string]challenge
+	lastPrune  time.Time
+
+	// options
+
+	// Addr is the UDP address listened by ListenAndServe. Defaults to DefaultServerAddr.
+	Addr string
+	// Stat returns the current full stat of the server, from which basic stats are built too.
+	// Missing vanilla properties are filled with defaults (e.g. numplayers is the number of online players, hostport and hostip are the listened ones).
+	Stat func() FullStat
+	// ChallengeExpiry is the duration after which a challenge token is not valid anymore. Defaults to DefaultChallengeExpiry.
+	ChallengeExpiry time.Duration
+}
+
+// NewServer returns a well-formed *Server.
+func NewServer(addr string, stat func() FullStat) *Server {
+	return &Server{
+		challenges: make(map[string]challenge),
+
+		Addr:            addr,
+		Stat:            stat,
+		ChallengeExpiry: DefaultChallengeExpiry,
+	}
+}
+
+// ListenAndServe listens on Addr, and serves requests until the server is closed. It always returns a non-nil error, ErrServerClosed after Close.
+func (server *Server) ListenAndServe() error {
+	addr := server.Addr
+	if addr == "" {
+		addr = DefaultServerAddr
+	}
+
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	return server.Serve(conn)
+}
+
+// Serve serves the requests received by conn until the server is closed. conn is closed when Serve returns.
+// It always returns a non-nil error, ErrServerClosed after Close.
+func (server *Server) Serve(conn net.PacketConn) error {
+	server.mutex.Lock()
+	if server.closed {
+		server.mutex.Unlock()
+		conn.Close()
+		return ErrServerClosed
+	}
+	if server.challenges == nil {
+		server.challenges = make(map[string]challenge)
+	}
+	server.conn = conn
+	server.mutex.Unlock()
+
+	defer conn.Close()
+
+	var buf [networking.MaximumUDPDatagramLength]byte
+	for {
+		n, addr, err := conn.ReadFrom(buf[:])
+		if err != nil {
+			server.mutex.Lock()
+			defer server.mutex.Unlock()
+
+			if server.closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		response, ok := server.handle(addr, networking.NewInput(bytes.NewReader(buf[:n])))
+		if ok {
+			conn.WriteTo(response.Bytes(), addr)
+		}
+	}
+}
+
+// Close stops the server.
+func (server *Server) Close() error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.closed {
+		return ErrServerClosed
+	}
+	server.closed = true
+
+	if server.conn != nil {
+		return server.conn.Close()
+	}
+	return nil
+}
+
+// handle returns the response to a request, if it must be answered.
+func (server *Server) handle(addr net.Addr, in networking.Input) (networking.Output, bool) {
+	req, err := parseRequest(in)
+	if err != nil {
+		return networking.Output{}, false
+	}
+
+	switch req.Type {
+	case HandshakeType:
+		token, err := server.issueChallenge(addr)
+		if err != nil {
+			return networking.Output{}, false
+		}
+		return generateHandshakeResponse(req.SessionID, token), true
+	case StatType:
+		if !server.validChallenge(addr, req.ChallengeToken) {
+			return networking.Output{}, false
+		}
+
+		fs := server.fullStat()
+		if req.Full {
+			return generateFullStatResponse(req.SessionID, fs), true
+		}
+		return generateBasicStatResponse(req.SessionID, basicStatFromFullStat(fs)), true
+	}
+
+	return networking.Output{}, false
+}
+
+// issueChallenge issues a new challenge token for addr, replacing the previous one.
+func (server *Server) issueChallenge(addr net.Addr) (uint32, error) {
+	token, err := generateChallengeToken()
+	if err != nil {
+		return 0, err
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	now := time.Now()
+	server.pruneChallenges(now)
+	server.challenges[addr.String()] = challenge{token: token, created: now}
+
+	return token, nil
+}
+
+// validChallenge reports whether token is the valid (i.e. not expired) challenge token of addr.
+func (server *Server) validChallenge(addr net.Addr, token uint32) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	c, ok := server.challenges[addr.String()]
+	if !ok || c.token != token {
+		return false
+	}
+
+	if time.Since(c.created) > server.challengeExpiry() {
+		delete(server.challenges, addr.String())
+		return false
+	}
+
+	return true
+}
+
+// pruneChallenges removes expired challenges, at most once per expiry duration. server.mutex must be held.
+func (server *Server) pruneChallenges(now time.Time) {
+	expiry := server.challengeExpiry()
+	if now.Sub(server.lastPrune) < expiry {
+		return
+	}
+	server.lastPrune = now
+
+	for addr, c := range server.challenges {
+		if now.Sub(c.created) > expiry {
+			delete(server.challenges, addr)
+		}
+	}
+}
+
+// challengeExpiry returns the challenge expiry duration, or its default.
+func (server *Server) challengeExpiry() time.Duration {
+	if server.ChallengeExpiry <= 0 {
+		return DefaultChallengeExpiry
+	}
+	return server.ChallengeExpiry
+}
+
+// fullStat returns the full stat of the server, with missing vanilla properties filled with defaults.
+func (server *Server) fullStat() FullStat {
+	var fs FullStat
+	if server.Stat != nil {
+		fs = server.Stat()
+	}
+
+	hostIP, hostPort := "0.0.0.0", "0"
+	if addr, ok := server.conn.LocalAddr().(*net.UDPAddr); ok {
+		if !addr.IP.IsUnspecified() {
+			hostIP = addr.IP.String()
+		}
+		hostPort = strconv.Itoa(addr.Port)
+	}
+
+	defaults := map[string]string{
+		"hostname":   "A Minecraft Server",
+		"gametype":   "SMP",
+		"game_id":    "MINECRAFT",
+		"version":    "",
+		"plugins":    "",
+		"map":        "world",
+		"numplayers": strconv.Itoa(len(fs.OnlinePlayers)),
+		"maxplayers": "20",
+		"hostport":   hostPort,
+		"hostip":     hostIP,
+	}
+
+	properties := make(map[string]string, len(fs.Properties)+len(defaults))
+	for key, value := range defaults {
+		properties[key] = value
+	}
+	for key, value := range fs.Properties {
+		properties[key] = value
+	}
+
+	return FullStat{
+		Properties:    properties,
+		OnlinePlayers: fs.OnlinePlayers,
+	}
+}
+
+// basicStatFromFullStat builds a basic stat from the properties of a full stat.
+func basicStatFromFullStat(fs FullStat) BasicStat {
+	numPlayers, _ := strconv.Atoi(fs.Properties["numplayers"])
+	maxPlayers, _ := strconv.Atoi(fs.Properties["maxplayers"])
+	hostPort, _ := strconv.Atoi(fs.Properties["hostport"])
+
+	return BasicStat{
+		MOTD:       fs.Properties["hostname"],
+		GameType:   fs.Properties["gametype"],
+		Map:        fs.Properties["map"],
+		NumPlayers: numPlayers,
+		MaxPlayers: maxPlayers,
+		HostPort:   hostPort,
+		HostIP:     fs.Properties["hostip"],
+	}
+}
@@ -0,0 +1,231 @@
+package query
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+// startTestServer starts a query server, and returns its port.
+func startTestServer(t *testing.T, server *Server) int {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	go server.Serve(conn)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func testStat() FullStat {
+	return FullStat{
+		Properties: map[string]string{
+			"hostname":   "Lobby",
+			"version":    "1.20.4",
+			"maxplayers": "100",
+			"custom":     "value",
+		},
+		OnlinePlayers: []string{"Notch", "jeb_"},
+	}
+}
+
+func TestServerStats(t *testing.T) {
+	port := startTestServer(t, NewServer("", testStat))
+
+	bs, err := QueryBasic("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	expectedBasicStat := BasicStat{
+		MOTD:       "Lobby",
+		GameType:   "SMP",
+		Map:        "world",
+		NumPlayers: 2,
+		MaxPlayers: 100,
+		HostPort:   port,
+		HostIP:     "127.0.0.1",
+	}
+	if bs != expectedBasicStat {
+		t.Errorf("Expected %v got %v.", expectedBasicStat, bs)
+	}
+
+	fs, err := QueryFull("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	inputs := []string{"hostname", "gametype", "game_id", "version", "map", "numplayers", "maxplayers", "hostip", "custom"}
+	expectedValues := []string{"Lobby", "SMP", "MINECRAFT", "1.20.4", "world", "2", "100", "127.0.0.1", "value"}
+
+	for i := 0; i < len(inputs); i++ {
+		if fs.Properties[inputs[i]] != expectedValues[i] {
+			t.Errorf("Value %d: Expected %s got %s.", i, expectedValues[i], fs.Properties[inputs[i]])
+		}
+	}
+	if !reflect.DeepEqual(fs.OnlinePlayers, testStat().OnlinePlayers) {
+		t.Errorf("Expected %v got %v.", testStat().OnlinePlayers, fs.OnlinePlayers)
+	}
+}
+
+func TestServerChallenge(t *testing.T) {
+	server := NewServer("", testStat)
+	server.ChallengeExpiry = 300 * time.Millisecond
+	port := startTestServer(t, server)
+
+	client := NewClient("127.0.0.1", port)
+	client.ReadTimeout = 100 * time.Millisecond
+
+	err := client.Connect()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer client.Disconnect()
+
+	token, err := client.Handshake()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	_, err = client.BasicStat(token + 1)
+	if err == nil {
+		t.Errorf("Expected error got <nil>.")
+	}
+
+	_, err = client.BasicStat(token)
+	if err != nil {
+		t.Errorf("Expected <nil> got %v.", err)
+	}
+
+	time.Sleep(350 * time.Millisecond)
+
+	_, err = client.FullStat(token)
+	if err == nil {
+		t.Errorf("Expected error got <nil>.")
+	}
+}
+
+// lossyPacketConn is a packet connection which drops the first datagrams it receives, and sends a copy of each datagram with another session id before it.
+type lossyPacketConn struct {
+	net.PacketConn
+	drop int
+}
+
+func (c *lossyPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		n, addr, err := c.PacketConn.ReadFrom(b)
+		if err != nil || c.drop == 0 {
+			return n, addr, err
+		}
+		c.drop--
+	}
+}
+
+func (c *lossyPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	mismatched := make([]byte, len(b))
+	copy(mismatched, b)
+	mismatched[4] ^= 0x01
+	c.PacketConn.WriteTo(mismatched, addr)
+
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+func TestClientRetransmission(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	server := NewServer("", testStat)
+	go server.Serve(&lossyPacketConn{PacketConn: conn, drop: 1})
+	defer server.Close()
+
+	client := NewClient("127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port)
+	client.Retry = networking.RetryPolicy{Attempts: 3, Timeout: 50 * time.Millisecond, Backoff: 2}
+
+	err = client.Connect()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer client.Disconnect()
+
+	// The first handshake request is lost, and the responses for another session are discarded.
+	token, err := client.Handshake()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if client.LastAttempts() != 2 {
+		t.Errorf("Expected 2 got %d.", client.LastAttempts())
+	}
+
+	bs, err := client.BasicStat(token)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if bs.MOTD != "Lobby" {
+		t.Errorf("Expected Lobby got %s.", bs.MOTD)
+	}
+	if client.LastAttempts() != 1 {
+		t.Errorf("Expected 1 got %d.", client.LastAttempts())
+	}
+}
+
+// slowPacketConn is a packet connection whose first write is delayed, blocking the server meanwhile.
+type slowPacketConn struct {
+	net.PacketConn
+	delay   time.Duration
+	written bool
+}
+
+func (c *slowPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if !c.written {
+		c.written = true
+		time.Sleep(c.delay)
+	}
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+func TestClientLateHandshake(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	server := NewServer("", testStat)
+	go server.Serve(&slowPacketConn{PacketConn: conn, delay: 80 * time.Millisecond})
+	defer server.Close()
+
+	client := NewClient("127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port)
+	client.Retry = networking.RetryPolicy{Attempts: 3, Timeout: 50 * time.Millisecond, Backoff: 2}
+	client.ReadTimeout = time.Second
+
+	err = client.Connect()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer client.Disconnect()
+
+	// The first reply arrives after the handshake has been retransmitted : its token has been replaced by the one of the second reply.
+	token, err := client.Handshake()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if client.LastAttempts() != 2 {
+		t.Errorf("Expected 2 got %d.", client.LastAttempts())
+	}
+
+	bs, err := client.BasicStat(token)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if bs.MOTD != "Lobby" || client.LastAttempts() != 1 {
+		t.Errorf("Expected Lobby, 1 got %s, %d.", bs.MOTD, client.LastAttempts())
+	}
+}
@@ -0,0 +1,40 @@
+package rcon
+
+import (
+	"context"
+)
+
+// CommandResult is the result of a command executed in a batch.
+type CommandResult struct {
+	Command  string
+	Response string
+	// Err is the error which made the command fail, if any.
+	Err error
+}
+
+// Batch executes commands one after another, in order, and returns the result of each executed command.
+// Execution stops at the first failing command : its result (with Err set) is the last one, the following commands are not sent, and its error is returned.
+func (client *RCONClient) Batch(commands []string) ([]CommandResult, error) {
+	return client.BatchContext(context.Background(), commands)
+}
+
+// BatchContext is the same as Batch, but the execution is aborted as soon as ctx is done.
+func (client *RCONClient) BatchContext(ctx context.Context, commands []string) ([]CommandResult, error) {
+	results := make([]CommandResult, 0, len(commands))
+
+	for _, command := range commands {
+		response, err := client.CommandContext(ctx, command)
+
+		results = append(results, CommandResult{
+			Command:  command,
+			Response: response,
+			Err:      err,
+		})
+
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
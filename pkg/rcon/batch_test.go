@@ -0,0 +1,49 @@
+package rcon
+
+import (
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	port := startTestServerWithOptions(t, "password", echoHandler, testServerOptions{
+		CloseOn: func(command string) bool {
+			return command == "stop"
+		},
+	})
+
+	inputs := [][]string{
+		{"save-off", "save-all flush", "long", "save-on"},
+		{"save-off", "stop", "save-on"},
+		{},
+	}
+	expectedLengths := []int{4, 2, 0}
+	expectedErrors := []bool{false, true, false}
+
+	for i := 0; i < len(inputs); i++ {
+		results, err := RconBatch("127.0.0.1", port, "password", inputs[i])
+		if (err != nil) != expectedErrors[i] {
+			t.Errorf("Error %d: Expected error %v got %v.", i, expectedErrors[i], err)
+		}
+		if len(results) != expectedLengths[i] {
+			t.Errorf("Value %d: Expected %d results got %d.", i, expectedLengths[i], len(results))
+			continue
+		}
+
+		for j, result := range results {
+			if result.Command != inputs[i][j] {
+				t.Errorf("Value %d.%d: Expected %s got %s.", i, j, inputs[i][j], result.Command)
+			}
+			if result.Err == nil && result.Response != echoHandler(result.Command) {
+				t.Errorf("Value %d.%d: Expected %d bytes got %d bytes.", i, j, len(echoHandler(result.Command)), len(result.Response))
+			}
+			if (result.Err != nil) != (expectedErrors[i] && j == len(results)-1) {
+				t.Errorf("Error %d.%d: Unexpected error %v.", i, j, result.Err)
+			}
+		}
+	}
+
+	_, err := RconBatch("127.0.0.1", port, "wrong", inputs[0])
+	if err != ErrWrongPassword {
+		t.Errorf("Expected %v got %v.", ErrWrongPassword, err)
+	}
+}
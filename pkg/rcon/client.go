@@ -2,8 +2,8 @@ package rcon
 
 import (
 	"bytes"
+	"context"
 	"errors"
-	"fmt"
 	"math/rand"
 	"net"
 	"time"
@@ -64,7 +64,7 @@ func generateCommandRequest(requestID uint32, command string) networking.Output
 }
 
 // generateInvalidRequest generates an invalid request, that will not be understood by the server.
-// It is useful to defragment multi-packet response.
+// It is useful to defragment multi-packet response (see SentinelPacket).
 func generateInvalidRequest(requestID uint32) networking.Output {
 	out := networking.NewOutput()
 
@@ -79,20 +79,42 @@ func generateInvalidRequest(requestID uint32) networking.Output {
 	return out
 }
 
+// generateEmptyResponse generates an empty response packet, which is mirrored by source rcon servers.
+// It is useful to defragment multi-packet response (see EmptyResponseMarker).
+func generateEmptyResponse(requestID uint32) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteLittleEndianInt32(requestID)
+
+	out.WriteLittleEndianInt32(uint32(CommandResponseType))
+
+	out.WriteNullTerminatedString("")
+
+	out.WriteSingleByte(0)
+
+	return out
+}
+
 // parsePacket reads and parses an input into a *packet.
 func parsePacket(in networking.Input) (*packet, error) {
-	var p packet
-
 	length, err := in.ReadLittleEndianInt32()
 	if err != nil {
 		return nil, err
 	}
-	p.Length = length
 
 	content, err := in.ReadBytes(int(length))
 	if err != nil {
 		return nil, err
 	}
+
+	return parsePacketContent(length, content)
+}
+
+// parsePacketContent parses the content of a packet (everything after its length) into a *packet.
+func parsePacketContent(length uint32, content []byte) (*packet, error) {
+	var p packet
+	p.Length = length
+
 	_in := networking.NewInput(bytes.NewReader(content))
 
 	requestID, err := _in.ReadLittleEndianInt32()
@@ -122,45 +144,6 @@ func parsePacket(in networking.Input) (*packet, error) {
 	return &p, nil
 }
 
-// parseFragmentedPacket reads and parse a response into a packet, and return its payload as a string.
-// If the payload is fragmented into multiple packets, it will send and invalid packet to the server and read all of the packets received (including the invalid response), to defragment the multi-packet response.
-// In this case, packet returned is the first recieved, and the payload return is the concatenation of all the payloads.
-func parseFragmentedPacketPayload(conn networking.TCPConn, in networking.Input) (*packet, string, error) {
-	var buf *bytes.Buffer = &bytes.Buffer{}
-	p, err := parsePacket(in)
-	if err != nil {
-		return nil, "", err
-	}
-	buf.WriteString(p.Payload)
-
-	if len(p.Payload) >= MaximumResponsePayloadLength {
-		invalidRequest := generateInvalidRequest(uint32(p.RequestID))
-		invalidRequestPacket := transformToPacket(invalidRequest)
-		_, err = conn.Send(invalidRequestPacket) // response is the same as the one transmitted in param
-		if err != nil {
-			return nil, "", err
-		}
-
-		var tmpPacket *packet
-		for {
-			tmpPacket, err = parsePacket(in)
-			if err != nil {
-				return nil, "", err
-			}
-
-			if tmpPacket.Payload == fmt.Sprintf("Unknown request %x", InvalidRequestType) {
-				break
-			}
-
-			if tmpPacket.RequestID == p.RequestID {
-				buf.WriteString(tmpPacket.Payload)
-			}
-		}
-	}
-
-	return p, buf.String(), nil
-}
-
 // RCONClient is the RCON client.
 type RCONClient struct {
 	hostname      string
@@ -169,9 +152,20 @@ type RCONClient struct {
 	authenticated bool
 
 	// options
-	SkipSRVLookup bool
-	DialTimeout   time.Duration
-	ReadTimeout   time.Duration
+	SkipSRVLookup  bool
+	DirectFallback networking.DirectFallback
+	DialTimeout    time.Duration
+	ReadTimeout    time.Duration
+	// Resolver is used for the SRV lookup, and Dialer to open the connection. They default to the ones of the net package.
+	Resolver networking.Resolver
+	Dialer   networking.Dialer
+	// ProxyProtocol is the version of the PROXY protocol header sent at the beginning of the connection, if any. Defaults to none.
+	// The source address of the header defaults to the local address of the connection.
+	ProxyProtocol           networking.ProxyProtocolVersion
+	ProxyProtocolSourceAddr *net.TCPAddr
+
+	// Reassembly is the strategy used to reassemble responses fragmented into multiple packets. Defaults to SentinelPacket{}.
+	Reassembly ReassemblyStrategy
 }
 
 // NewClient returns a well-formed *RCONClient.
@@ -190,18 +184,29 @@ func NewClient(hostname string, port int) *RCONClient {
 		SkipSRVLookup: skipSRVLookup,
 		DialTimeout:   5 * time.Second,
 		ReadTimeout:   5 * time.Second,
+		Reassembly:    SentinelPacket{},
 	}
 }
 
 // Connect establishes a connection via TCP.
 func (client *RCONClient) Connect() error {
+	return client.ConnectContext(context.Background())
+}
+
+// ConnectContext is the same as Connect, but the connection attempt is aborted as soon as ctx is done.
+func (client *RCONClient) ConnectContext(ctx context.Context) error {
 	if client.conn != nil {
 		return networking.ErrConnectionAlreadyEstablished
 	}
 
-	conn, err := networking.DialTCP(client.hostname, client.port, networking.DialTCPOptions{
-		SkipSRVLookup: client.SkipSRVLookup,
-		DialTimeout:   client.DialTimeout,
+	conn, err := networking.DialTCPContext(ctx, client.hostname, client.port, networking.DialTCPOptions{
+		SkipSRVLookup:           client.SkipSRVLookup,
+		DirectFallback:          client.DirectFallback,
+		DialTimeout:             client.DialTimeout,
+		Resolver:                client.Resolver,
+		Dialer:                  client.Dialer,
+		ProxyProtocol:           client.ProxyProtocol,
+		ProxyProtocolSourceAddr: client.ProxyProtocolSourceAddr,
 	})
 	if err != nil {
 		return err
@@ -215,6 +220,12 @@ func (client *RCONClient) Connect() error {
 // If connection is successful it returns true, if not it returns false.
 // If the communication didn't go wrong, err will be nil. This means that (false, nil) is a perfectly fine return if the password was wrong but he communication went well.
 func (client *RCONClient) Authenticate(password string) (bool, error) {
+	return client.AuthenticateContext(context.Background(), password)
+}
+
+// AuthenticateContext is the same as Authenticate, but the request is aborted as soon as ctx is done.
+// If the communication fails, the connection is closed, and the client must be connected again.
+func (client *RCONClient) AuthenticateContext(ctx context.Context, password string) (bool, error) {
 	if client.conn == nil {
 		return false, networking.ErrConnectionNotEstablished
 	}
@@ -225,19 +236,19 @@ func (client *RCONClient) Authenticate(password string) (bool, error) {
 
 	loginRequestPacket := transformToPacket(loginRequest)
 
-	loginResponse, err := client.conn.Send(loginRequestPacket)
+	loginResponse, err := client.conn.SendContext(ctx, loginRequestPacket)
 	if err != nil {
-		return false, err
+		return false, client.abort(err)
 	}
 
 	err = client.conn.SetReadDeadline(client.ReadTimeout)
 	if err != nil {
-		return false, err
+		return false, client.abort(err)
 	}
 
 	packet, err := parsePacket(loginResponse)
 	if err != nil {
-		return false, err
+		return false, client.abort(err)
 	}
 
 	if packet.RequestID == -1 {
@@ -251,6 +262,12 @@ func (client *RCONClient) Authenticate(password string) (bool, error) {
 // Command sends a command packet to the server.
 // Command length cannot be over MaximumRequestPayloadLength. This is a limitation of the source RCON protocol.
 func (client *RCONClient) Command(command string) (string, error) {
+	return client.CommandContext(context.Background(), command)
+}
+
+// CommandContext is the same as Command, but the request is aborted as soon as ctx is done.
+// If the communication fails, the connection is closed, and the client must be connected and authenticated again.
+func (client *RCONClient) CommandContext(ctx context.Context, command string) (string, error) {
 	if len(command) > MaximumRequestPayloadLength {
 		return "", ErrCommandTooLong
 	}
@@ -267,26 +284,51 @@ func (client *RCONClient) Command(command string) (string, error) {
 
 	commandRequestPacket := transformToPacket(commandRequest)
 
-	commandResponse, err := client.conn.Send(commandRequestPacket)
+	commandResponse, err := client.conn.SendContext(ctx, commandRequestPacket)
 	if err != nil {
-		return "", err
+		return "", client.abort(err)
 	}
 
 	err = client.conn.SetReadDeadline(client.ReadTimeout)
 	if err != nil {
-		return "", err
+		return "", client.abort(err)
 	}
 
-	packet, defragementedPayload, err := parseFragmentedPacketPayload(*client.conn, commandResponse)
+	packet, err := parsePacket(commandResponse)
 	if err != nil {
-		return "", err
+		return "", client.abort(err)
 	}
 
+	// The server doesn't consider the client authenticated anymore : it has to authenticate again.
 	if packet.RequestID == -1 {
+		client.authenticated = false
 		return "", ErrNotAuthenticated
 	}
 
-	return defragementedPayload, nil
+	reassembly := client.Reassembly
+	if reassembly == nil {
+		reassembly = SentinelPacket{}
+	}
+
+	payload, err := reassembly.reassemble(ctx, client.conn, commandResponse, packet)
+	if err != nil {
+		return "", client.abort(err)
+	}
+
+	return payload, nil
+}
+
+// abort closes the connection after an I/O error, as its state is unknown : a response may have been partially read.
+// The client can then be connected again. It returns err.
+func (client *RCONClient) abort(err error) error {
+	client.authenticated = false
+
+	if client.conn != nil {
+		client.conn.Close()
+		client.conn = nil
+	}
+
+	return err
 }
 
 // Disconnect closes the connection. This also means the authentication isn't valid anymore, even if a call to the Connect method is made.
@@ -302,3 +344,12 @@ func (client *RCONClient) Disconnect() error {
 	client.conn = nil
 	return err
 }
+
+// Target returns the address the client is connected to, which differs from its hostname and port if it comes from a SRV record.
+func (client *RCONClient) Target() (networking.Target, error) {
+	if client.conn == nil {
+		return networking.Target{}, networking.ErrConnectionNotEstablished
+	}
+
+	return client.conn.Target(), nil
+}
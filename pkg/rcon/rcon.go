@@ -2,7 +2,10 @@
 // This package is strictly compliant with the following documentation : https://minecraft.wiki/w/RCON.
 package rcon
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 var (
 	ErrWrongPassword error = errors.New("wrong password")
@@ -11,25 +14,33 @@ var (
 // Rcon executes a command on a minecraft server, and returns the response of that command.
 // If the password is wrong, the error will be of type ErrWrongPassword.
 func Rcon(hostname string, port int, password string, command string) (string, error) {
+	return RconContext(context.Background(), hostname, port, password, command)
+}
+
+// RconContext is the same as Rcon, but the whole process is aborted as soon as ctx is done.
+func RconContext(ctx context.Context, hostname string, port int, password string, command string) (string, error) {
 	client := NewClient(hostname, port)
 
-	err := client.Connect()
+	err := client.ConnectContext(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	ok, err := client.Authenticate(password)
+	ok, err := client.AuthenticateContext(ctx, password)
 	if err != nil {
+		client.Disconnect()
 		return "", err
 	}
 
 	if !ok {
+		client.Disconnect()
 		return "", ErrWrongPassword
 	}
 
-	response, err := client.Command(command)
+	response, err := client.CommandContext(ctx, command)
 	if err != nil {
-		return "", nil
+		client.Disconnect()
+		return "", err
 	}
 
 	err = client.Disconnect()
@@ -39,3 +50,68 @@ func Rcon(hostname string, port int, password string, command string) (string, e
 
 	return response, nil
 }
+
+// RconBatch executes commands one after another on a minecraft server, and returns the result of each executed command.
+// Execution stops at the first failing command, whose error is returned (see RCONClient.Batch).
+// If the password is wrong, the error will be of type ErrWrongPassword.
+func RconBatch(hostname string, port int, password string, commands []string) ([]CommandResult, error) {
+	return RconBatchContext(context.Background(), hostname, port, password, commands)
+}
+
+// RconBatchContext is the same as RconBatch, but the whole process is aborted as soon as ctx is done.
+func RconBatchContext(ctx context.Context, hostname string, port int, password string, commands []string) ([]CommandResult, error) {
+	client := NewClient(hostname, port)
+
+	err := client.ConnectContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := client.AuthenticateContext(ctx, password)
+	if err != nil {
+		client.Disconnect()
+		return nil, err
+	}
+
+	if !ok {
+		client.Disconnect()
+		return nil, ErrWrongPassword
+	}
+
+	results, err := client.BatchContext(ctx, commands)
+	if client.conn != nil {
+		client.Disconnect()
+	}
+
+	return results, err
+}
+
+// OpenSession connects and authenticates to a minecraft server, and returns a session which can be used to execute commands concurrently.
+// Commands are queued and sent one at a time (see Session), as vanilla servers close the connection when they receive more than one packet at once.
+// If the password is wrong, the error will be of type ErrWrongPassword. The session must be closed with Session.Close.
+func OpenSession(hostname string, port int, password string) (*Session, error) {
+	return OpenSessionContext(context.Background(), hostname, port, password)
+}
+
+// OpenSessionContext is the same as OpenSession, but the connection and the authentication are aborted as soon as ctx is done.
+func OpenSessionContext(ctx context.Context, hostname string, port int, password string) (*Session, error) {
+	client := NewClient(hostname, port)
+
+	err := client.ConnectContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := client.AuthenticateContext(ctx, password)
+	if err != nil {
+		client.Disconnect()
+		return nil, err
+	}
+
+	if !ok {
+		client.Disconnect()
+		return nil, ErrWrongPassword
+	}
+
+	return client.Session()
+}
@@ -0,0 +1,184 @@
+package rcon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+const (
+	DefaultIdleTimeout time.Duration = 250 * time.Millisecond
+)
+
+var (
+	ErrTruncatedPacket error = errors.New("connection idle in the middle of a packet")
+)
+
+// ReassemblyStrategy is the way the client finds the end of a command response, which may be fragmented into multiple packets.
+// Available strategies are SentinelPacket (the default), EmptyResponseMarker and IdleTimeout.
+// Its method is unexported, as it works on the packets of the client : only these built-in strategies can be used.
+type ReassemblyStrategy interface {
+	// reassemble reads the remaining packets of the response whose first packet is first, and returns the whole payload.
+	reassemble(ctx context.Context, conn *networking.TCPConn, in networking.Input, first *packet) (string, error)
+}
+
+// SentinelPacket sends an invalid request (of type InvalidRequestType) after the command, with its own request ID.
+// Servers answer requests in order, so the response to the invalid request marks the end of the command response, whatever its payload is.
+// This works on vanilla servers and their forks, even if they change the "Unknown request" message.
+// Unless Always is true, the invalid request is only sent when the first packet of the response is full (MaximumResponsePayloadLength bytes), as vanilla servers do.
+type SentinelPacket struct {
+	Always bool
+}
+
+// reassemble implements ReassemblyStrategy.
+func (sp SentinelPacket) reassemble(ctx context.Context, conn *networking.TCPConn, in networking.Input, first *packet) (string, error) {
+	if !sp.Always && len(first.Payload) < MaximumResponsePayloadLength {
+		return first.Payload, nil
+	}
+
+	sentinelID := nextRequestID(first.RequestID)
+	invalidRequestPacket := transformToPacket(generateInvalidRequest(uint32(sentinelID)))
+
+	err := conn.WriteContext(ctx, invalidRequestPacket)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBufferString(first.Payload)
+	for {
+		p, err := parsePacket(in)
+		if err != nil {
+			return "", err
+		}
+
+		if p.RequestID == sentinelID {
+			return buf.String(), nil
+		}
+
+		if p.RequestID == first.RequestID {
+			buf.WriteString(p.Payload)
+		}
+	}
+}
+
+// EmptyResponseMarker sends an empty response packet (of type CommandResponseType) after the command, with its own request ID.
+// This is the technique recommended by the Source RCON protocol : servers mirror the empty packet, and then send a packet whose payload is 0x00000001.
+// On servers which answer it as an invalid request (e.g. vanilla servers), the answer still marks the end of the command response.
+// Unless Always is true, the marker is only sent when the first packet of the response is full (MaximumResponsePayloadLength bytes).
+type EmptyResponseMarker struct {
+	Always bool
+}
+
+// reassemble implements ReassemblyStrategy.
+func (erm EmptyResponseMarker) reassemble(ctx context.Context, conn *networking.TCPConn, in networking.Input, first *packet) (string, error) {
+	if !erm.Always && len(first.Payload) < MaximumResponsePayloadLength {
+		return first.Payload, nil
+	}
+
+	markerID := nextRequestID(first.RequestID)
+	emptyResponsePacket := transformToPacket(generateEmptyResponse(uint32(markerID)))
+
+	err := conn.WriteContext(ctx, emptyResponsePacket)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBufferString(first.Payload)
+	for {
+		p, err := parsePacket(in)
+		if err != nil {
+			return "", err
+		}
+
+		if p.RequestID == markerID {
+			// Source servers send a second packet after mirroring the empty response.
+			if p.Payload == "" {
+				_, err = parsePacket(in)
+				if err != nil {
+					return "", err
+				}
+			}
+			return buf.String(), nil
+		}
+
+		if p.RequestID == first.RequestID {
+			buf.WriteString(p.Payload)
+		}
+	}
+}
+
+// IdleTimeout considers the response complete when no packet has been received for Timeout (DefaultIdleTimeout if 0).
+// It doesn't send anything to the server, so it works with any server, but it adds Timeout to the duration of each fragmented response.
+// If the connection is idle in the middle of a packet, the response is incomplete : ErrTruncatedPacket is returned, and the connection is closed.
+// Unless Always is true, more packets are only awaited when the first packet of the response is full (MaximumResponsePayloadLength bytes).
+type IdleTimeout struct {
+	Timeout time.Duration
+	Always  bool
+}
+
+// reassemble implements ReassemblyStrategy.
+func (it IdleTimeout) reassemble(ctx context.Context, conn *networking.TCPConn, in networking.Input, first *packet) (string, error) {
+	if !it.Always && len(first.Payload) < MaximumResponsePayloadLength {
+		return first.Payload, nil
+	}
+
+	timeout := it.Timeout
+	if timeout == 0 {
+		timeout = DefaultIdleTimeout
+	}
+
+	buf := bytes.NewBufferString(first.Payload)
+	for {
+		err := conn.SetReadDeadline(timeout)
+		if err != nil {
+			return "", err
+		}
+
+		// Only a timeout before the first byte of a packet marks the end of the response.
+		var firstByte [1]byte
+		_, err = io.ReadFull(&in, firstByte[:])
+		if isIdle(ctx, err) {
+			return buf.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		err = conn.SetReadDeadline(timeout)
+		if err != nil {
+			return "", err
+		}
+
+		p, err := parsePacket(networking.NewInput(io.MultiReader(bytes.NewReader(firstByte[:]), &in)))
+		if isIdle(ctx, err) {
+			return "", ErrTruncatedPacket
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if p.RequestID == first.RequestID {
+			buf.WriteString(p.Payload)
+		}
+	}
+}
+
+// isIdle tells whether err is a read timeout, which isn't caused by ctx.
+func isIdle(ctx context.Context, err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil
+}
+
+// nextRequestID returns the request ID following requestID, skipping -1 which is the ID of authentication failures.
+func nextRequestID(requestID int32) int32 {
+	next := requestID + 1
+	if next == -1 {
+		next = 0
+	}
+	return next
+}
@@ -0,0 +1,91 @@
+package rcon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReassembly(t *testing.T) {
+	long := strings.Repeat("abcdefgh", 1500)
+	handler := func(command string) string {
+		if command == "long" {
+			return long
+		}
+		return command
+	}
+
+	servers := []testServerOptions{
+		{UnknownRequest: "Requête inconnue 4"},
+		{FragmentLength: 1000},
+		{FragmentLength: 1000, Source: true},
+		{},
+		{FragmentLength: 1000},
+	}
+	strategies := []ReassemblyStrategy{
+		SentinelPacket{},
+		SentinelPacket{Always: true},
+		EmptyResponseMarker{Always: true},
+		EmptyResponseMarker{},
+		IdleTimeout{Timeout: 100 * time.Millisecond, Always: true},
+	}
+
+	for i := 0; i < len(servers); i++ {
+		port := startTestServerWithOptions(t, "password", handler, servers[i])
+
+		client := NewClient("127.0.0.1", port)
+		client.Reassembly = strategies[i]
+
+		err := client.Connect()
+		if err != nil {
+			t.Fatalf("Error %d: Expected <nil> got %v.", i, err)
+		}
+
+		ok, err := client.Authenticate("password")
+		if !ok || err != nil {
+			t.Fatalf("Error %d: Expected true, <nil> got %v, %v.", i, ok, err)
+		}
+
+		// Commands are sent twice, to check that no packet of the first response is left to be read.
+		for _, command := range []string{"long", "short", "long", "short"} {
+			res, err := client.Command(command)
+			if err != nil {
+				t.Errorf("Error %d: Expected <nil> got %v.", i, err)
+				break
+			}
+			if res != handler(command) {
+				t.Errorf("Value %d: Expected %d bytes got %d bytes.", i, len(handler(command)), len(res))
+			}
+		}
+
+		client.Disconnect()
+	}
+}
+
+func TestIdleTimeoutTruncatedPacket(t *testing.T) {
+	port := startTestServerWithOptions(t, "password", echoHandler, testServerOptions{FragmentLength: 1000, StallInPacket: true})
+
+	client := NewClient("127.0.0.1", port)
+	client.Reassembly = IdleTimeout{Timeout: 100 * time.Millisecond, Always: true}
+
+	err := client.Connect()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer client.Disconnect()
+
+	ok, err := client.Authenticate("password")
+	if !ok || err != nil {
+		t.Fatalf("Expected true, <nil> got %v, %v.", ok, err)
+	}
+
+	// The response stops after the length of its second packet : it must not be considered complete.
+	_, err = client.Command("long")
+	if !errors.Is(err, ErrTruncatedPacket) {
+		t.Errorf("Expected %v got %v.", ErrTruncatedPacket, err)
+	}
+	if client.conn != nil {
+		t.Errorf("Expected connection to be closed.")
+	}
+}
@@ -0,0 +1,345 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+var (
+	ErrClientClosed error = errors.New("rcon client is closed")
+)
+
+// ConnectionState is the state of the connection of a ResilientClient.
+type ConnectionState int
+
+const (
+	StateDisconnected ConnectionState = iota
+	StateConnecting
+	StateConnected
+	StateClosed
+)
+
+// String returns the name of the state.
+func (cs ConnectionState) String() string {
+	switch cs {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// ResilientClient is an RCON client which survives server restarts : when the connection is broken, it reconnects and authenticates again with the stored password.
+// Reconnection is done lazily, by the next command, with an exponential backoff between attempts.
+// It is safe for concurrent use by multiple goroutines, but commands are executed one at a time.
+type ResilientClient struct {
+	hostname string
+	port     int
+	password string
+
+	mutex  sync.Mutex
+	client *RCONClient
+
+	// closed is closed by Close, to abort the pending connection attempts and commands.
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	stateMutex sync.Mutex
+	state      ConnectionState
+	// changes are the state changes not yet passed to OnStateChange, which is only called once mutex is released.
+	changes []stateChange
+
+	// options
+
+	// SkipSRVLookup, DirectFallback, DialTimeout, ReadTimeout, Resolver, Dialer, ProxyProtocol, ProxyProtocolSourceAddr and Reassembly are the options of the underlying RCONClient.
+	SkipSRVLookup           bool
+	DirectFallback          networking.DirectFallback
+	DialTimeout             time.Duration
+	ReadTimeout             time.Duration
+	Resolver                networking.Resolver
+	Dialer                  networking.Dialer
+	ProxyProtocol           networking.ProxyProtocolVersion
+	ProxyProtocolSourceAddr *net.TCPAddr
+	Reassembly              ReassemblyStrategy
+
+	// MinBackoff is the delay before the second connection attempt. It is doubled after each failed attempt, up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts is the maximum number of connection attempts made by a single command. 0 means no limit (until the context is done).
+	MaxAttempts int
+
+	// Retry reports whether a command which failed because of a broken connection can be sent again after reconnecting.
+	// It should only return true for idempotent commands, as the command may have been executed before the connection broke. If nil, commands are never retried.
+	Retry func(command string) bool
+
+	// OnStateChange is called each time the connection state changes. err is the error which caused the change, if any.
+	// It is called once the client isn't in use anymore by the goroutine which changed the state, so it may use the client (e.g. Close it).
+	OnStateChange func(state ConnectionState, err error)
+}
+
+// stateChange is a change of the connection state, to pass to OnStateChange.
+type stateChange struct {
+	state ConnectionState
+	err   error
+}
+
+// NewResilientClient returns a well-formed *ResilientClient. The connection is established by Connect, or by the first command.
+func NewResilientClient(hostname string, port int, password string) *ResilientClient {
+	client := NewClient(hostname, port)
+
+	return &ResilientClient{
+		hostname: hostname,
+		port:     port,
+		password: password,
+		state:    StateDisconnected,
+		closed:   make(chan struct{}),
+
+		SkipSRVLookup: client.SkipSRVLookup,
+		DialTimeout:   client.DialTimeout,
+		ReadTimeout:   client.ReadTimeout,
+		Reassembly:    client.Reassembly,
+
+		MinBackoff:  500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+		MaxAttempts: 0,
+	}
+}
+
+// State returns the current connection state.
+func (rc *ResilientClient) State() ConnectionState {
+	rc.stateMutex.Lock()
+	defer rc.stateMutex.Unlock()
+
+	return rc.state
+}
+
+// Connect establishes and authenticates the connection, retrying with backoff.
+func (rc *ResilientClient) Connect() error {
+	return rc.ConnectContext(context.Background())
+}
+
+// ConnectContext is the same as Connect, but attempts are aborted as soon as ctx is done.
+func (rc *ResilientClient) ConnectContext(ctx context.Context) error {
+	rc.mutex.Lock()
+	defer rc.unlock()
+
+	ctx, cancel := rc.withClose(ctx)
+	defer cancel()
+
+	return rc.closedError(rc.ensureConnected(ctx))
+}
+
+// Command executes a command, reconnecting first if the connection is broken.
+// If the connection breaks during the command, the command is sent again after reconnecting only if Retry allows it.
+func (rc *ResilientClient) Command(command string) (string, error) {
+	return rc.CommandContext(context.Background(), command)
+}
+
+// CommandContext is the same as Command, but the whole process is aborted as soon as ctx is done.
+func (rc *ResilientClient) CommandContext(ctx context.Context, command string) (string, error) {
+	rc.mutex.Lock()
+	defer rc.unlock()
+
+	ctx, cancel := rc.withClose(ctx)
+	defer cancel()
+
+	retried := false
+	for {
+		err := rc.ensureConnected(ctx)
+		if err != nil {
+			return "", rc.closedError(err)
+		}
+
+		response, err := rc.client.CommandContext(ctx, command)
+
+		// The server doesn't consider the session authenticated anymore (e.g. after a restart behind a proxy) : the session is dropped.
+		// The command hasn't been executed, so it can be sent again after authenticating, whatever Retry says.
+		notAuthenticated := err == ErrNotAuthenticated
+		if notAuthenticated {
+			rc.client.Disconnect()
+		}
+
+		if err == nil || rc.client.conn != nil {
+			return response, err
+		}
+
+		// The connection has been closed by the client, because of an I/O error.
+		rc.setState(StateDisconnected, err)
+
+		if retried || ctx.Err() != nil || (!notAuthenticated && (rc.Retry == nil || !rc.Retry(command))) {
+			return "", rc.closedError(err)
+		}
+		retried = true
+	}
+}
+
+// Close closes the connection. The client can't be used anymore.
+// Pending connection attempts and commands are aborted, and return ErrClientClosed.
+func (rc *ResilientClient) Close() error {
+	closing := false
+	rc.closeOnce.Do(func() {
+		close(rc.closed)
+		closing = true
+	})
+	if !closing {
+		return ErrClientClosed
+	}
+
+	rc.mutex.Lock()
+	defer rc.unlock()
+
+	var err error
+	if rc.client != nil && rc.client.conn != nil {
+		err = rc.client.Disconnect()
+	}
+	rc.client = nil
+
+	rc.setState(StateClosed, nil)
+	return err
+}
+
+// ensureConnected connects and authenticates the client if needed, retrying with backoff.
+// A wrong password is not retried. rc.mutex must be held.
+func (rc *ResilientClient) ensureConnected(ctx context.Context) error {
+	select {
+	case <-rc.closed:
+		return ErrClientClosed
+	default:
+	}
+	if rc.client != nil && rc.client.conn != nil && rc.client.authenticated {
+		return nil
+	}
+
+	rc.setState(StateConnecting, nil)
+
+	backoff := rc.MinBackoff
+	for attempt := 1; ; attempt++ {
+		err := rc.connect(ctx)
+		if err == nil {
+			rc.setState(StateConnected, nil)
+			return nil
+		}
+
+		if err == ErrWrongPassword || ctx.Err() != nil || (rc.MaxAttempts > 0 && attempt >= rc.MaxAttempts) {
+			rc.setState(StateDisconnected, err)
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done(): // including Close, see withClose
+			timer.Stop()
+			rc.setState(StateDisconnected, ctx.Err())
+			return ctx.Err()
+		}
+
+		backoff *= 2
+		if rc.MaxBackoff > 0 && backoff > rc.MaxBackoff {
+			backoff = rc.MaxBackoff
+		}
+	}
+}
+
+// connect makes a single connection and authentication attempt, with a new RCONClient.
+func (rc *ResilientClient) connect(ctx context.Context) error {
+	if rc.client != nil && rc.client.conn != nil {
+		rc.client.Disconnect()
+	}
+
+	client := NewClient(rc.hostname, rc.port)
+	client.SkipSRVLookup = rc.SkipSRVLookup
+	client.DirectFallback = rc.DirectFallback
+	client.DialTimeout = rc.DialTimeout
+	client.ReadTimeout = rc.ReadTimeout
+	client.Resolver = rc.Resolver
+	client.Dialer = rc.Dialer
+	client.ProxyProtocol = rc.ProxyProtocol
+	client.ProxyProtocolSourceAddr = rc.ProxyProtocolSourceAddr
+	client.Reassembly = rc.Reassembly
+
+	err := client.ConnectContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ok, err := client.AuthenticateContext(ctx, rc.password)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		client.Disconnect()
+		return ErrWrongPassword
+	}
+
+	rc.client = client
+	return nil
+}
+
+// withClose returns a copy of ctx which is also done when the client is closed.
+func (rc *ResilientClient) withClose(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		select {
+		case <-rc.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// closedError returns ErrClientClosed in place of err if the client has been closed, as errors are then caused by the closing.
+func (rc *ResilientClient) closedError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	select {
+	case <-rc.closed:
+		return ErrClientClosed
+	default:
+		return err
+	}
+}
+
+// setState changes the connection state, and records the change for OnStateChange if it actually changed.
+func (rc *ResilientClient) setState(state ConnectionState, err error) {
+	rc.stateMutex.Lock()
+	defer rc.stateMutex.Unlock()
+
+	if rc.state != state {
+		rc.state = state
+		rc.changes = append(rc.changes, stateChange{state: state, err: err})
+	}
+}
+
+// unlock releases rc.mutex, then passes the recorded state changes to OnStateChange.
+func (rc *ResilientClient) unlock() {
+	rc.stateMutex.Lock()
+	changes := rc.changes
+	rc.changes = nil
+	rc.stateMutex.Unlock()
+
+	rc.mutex.Unlock()
+
+	if rc.OnStateChange == nil {
+		return
+	}
+	for _, change := range changes {
+		rc.OnStateChange(change.state, change.err)
+	}
+}
@@ -0,0 +1,227 @@
+package rcon
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+func TestResilientClientReconnect(t *testing.T) {
+	var crashes int32
+	port := startTestServerWithOptions(t, "password", echoHandler, testServerOptions{
+		CloseOn: func(command string) bool {
+			return command == "stop" || (command == "flaky" && atomic.AddInt32(&crashes, 1) == 1)
+		},
+	})
+
+	var mutex sync.Mutex
+	var states []ConnectionState
+
+	client := NewResilientClient("127.0.0.1", port, "password")
+	client.MinBackoff = 10 * time.Millisecond
+	client.Retry = func(command string) bool {
+		return command == "flaky"
+	}
+	client.OnStateChange = func(state ConnectionState, err error) {
+		mutex.Lock()
+		states = append(states, state)
+		mutex.Unlock()
+	}
+	defer client.Close()
+
+	inputs := []string{"list", "stop", "list", "flaky"}
+	expectedErrors := []bool{false, true, false, false}
+
+	for i := 0; i < len(inputs); i++ {
+		res, err := client.Command(inputs[i])
+		if (err != nil) != expectedErrors[i] {
+			t.Errorf("Error %d: Expected error %v got %v.", i, expectedErrors[i], err)
+			continue
+		}
+		if err == nil && res != inputs[i] {
+			t.Errorf("Value %d: Expected %s got %s.", i, inputs[i], res)
+		}
+	}
+
+	expectedStates := []ConnectionState{
+		StateConnecting, StateConnected, // list
+		StateDisconnected,               // stop
+		StateConnecting, StateConnected, // list
+		StateDisconnected, StateConnecting, StateConnected, // flaky, retried
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if len(states) != len(expectedStates) {
+		t.Fatalf("Expected %v got %v.", expectedStates, states)
+	}
+	for i := range states {
+		if states[i] != expectedStates[i] {
+			t.Errorf("Expected %v got %v.", expectedStates, states)
+			break
+		}
+	}
+}
+
+func TestResilientClientErrors(t *testing.T) {
+	port := startTestServer(t, "password", echoHandler)
+
+	client := NewResilientClient("127.0.0.1", port, "wrong")
+	client.MinBackoff = 10 * time.Millisecond
+
+	_, err := client.Command("list")
+	if err != ErrWrongPassword {
+		t.Errorf("Expected %v got %v.", ErrWrongPassword, err)
+	}
+
+	client = NewResilientClient("127.0.0.1", 1, "password")
+	client.MinBackoff = 10 * time.Millisecond
+	client.MaxAttempts = 3
+
+	err = client.Connect()
+	if err == nil || client.State() != StateDisconnected {
+		t.Errorf("Expected non nil, %v got %v, %v.", StateDisconnected, err, client.State())
+	}
+
+	client.Close()
+	_, err = client.Command("list")
+	if err != ErrClientClosed {
+		t.Errorf("Expected %v got %v.", ErrClientClosed, err)
+	}
+}
+
+func TestResilientClientForgottenAuthentication(t *testing.T) {
+	var forgotten int32
+	port := startTestServerWithOptions(t, "password", echoHandler, testServerOptions{
+		ForgetOn: func(command string) bool {
+			return atomic.AddInt32(&forgotten, 1) == 2
+		},
+	})
+
+	client := NewResilientClient("127.0.0.1", port, "password")
+	client.MinBackoff = 10 * time.Millisecond
+	defer client.Close()
+
+	// The second command is answered as if the client wasn't authenticated : it must authenticate again, and send the command again.
+	for i, command := range []string{"list", "list", "list"} {
+		res, err := client.Command(command)
+		if err != nil || res != command {
+			t.Errorf("Value %d: Expected %s, <nil> got %s, %v.", i, command, res, err)
+		}
+	}
+}
+
+func TestResilientClientClose(t *testing.T) {
+	// Close aborts a command waiting for the server, which never comes back.
+	client := NewResilientClient("127.0.0.1", 1, "password")
+	client.MinBackoff = 50 * time.Millisecond
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.Command("list")
+		result <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- client.Close()
+	}()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Expected <nil> got %v.", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Close to return.")
+	}
+	if err := <-result; err != ErrClientClosed {
+		t.Errorf("Expected %v got %v.", ErrClientClosed, err)
+	}
+
+	// OnStateChange may use the client.
+	client = NewResilientClient("127.0.0.1", 1, "password")
+	client.MaxAttempts = 1
+	client.OnStateChange = func(state ConnectionState, err error) {
+		if state == StateDisconnected {
+			client.Close()
+		}
+	}
+
+	connected := make(chan error, 1)
+	go func() {
+		connected <- client.Connect()
+	}()
+
+	select {
+	case <-connected:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Connect to return.")
+	}
+	if client.State() != StateClosed {
+		t.Errorf("Expected %v got %v.", StateClosed, client.State())
+	}
+}
+
+// redirectDialer is a Dialer which connects to addr, whatever the dialed address, and records the dialed addresses.
+type redirectDialer struct {
+	addr      string
+	addresses chan string
+}
+
+func (d redirectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.addresses <- address
+	return (&net.Dialer{}).DialContext(ctx, network, d.addr)
+}
+
+func TestResilientClientDialer(t *testing.T) {
+	port := startTestServer(t, "password", echoHandler)
+
+	dialer := redirectDialer{addr: net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), addresses: make(chan string, 1)}
+
+	client := NewResilientClient("mc.example.com", 25575, "password")
+	client.SkipSRVLookup = true
+	client.Dialer = dialer
+	defer client.Close()
+
+	res, err := client.Command("list")
+	if err != nil || res != "list" {
+		t.Fatalf("Expected list, <nil> got %s, %v.", res, err)
+	}
+
+	address := <-dialer.addresses
+	if address != "mc.example.com:25575" {
+		t.Errorf("Expected mc.example.com:25575 got %s.", address)
+	}
+}
+
+func TestResilientClientProxyProtocol(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	server := NewServer("", "password", HandlerFunc(echoHandler))
+	server.ProxyProtocol = true
+	go server.Serve(l)
+	defer server.Close()
+
+	client := NewResilientClient("127.0.0.1", l.Addr().(*net.TCPAddr).Port, "password")
+	client.ReadTimeout = time.Second
+	client.MaxAttempts = 1
+	client.ProxyProtocol = networking.ProxyProtocolV2
+	defer client.Close()
+
+	res, err := client.Command("list")
+	if err != nil || res != "list" {
+		t.Errorf("Expected list, <nil> got %s, %v.", res, err)
+	}
+}
@@ -0,0 +1,274 @@
+package rcon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+const (
+	DefaultServerAddr string = ":25575"
+	// MaximumRequestPacketLength is the maximum length of the packets accepted by the server (not including the length field).
+	MaximumRequestPacketLength int = MaximumRequestPayloadLength + PacketSizeEmptyPayload - 4
+)
+
+var (
+	ErrServerClosed error = errors.New("rcon server is closed")
+)
+
+// Handler executes the commands received by a Server.
+type Handler interface {
+	// ServeRCON executes command, and returns its response. It may be called concurrently by different connections.
+	ServeRCON(command string) string
+}
+
+// HandlerFunc is a function used as a Handler.
+type HandlerFunc func(command string) string
+
+// ServeRCON calls f(command).
+func (f HandlerFunc) ServeRCON(command string) string {
+	return f(command)
+}
+
+// generateResponse generates a networking.Output corresponding to a response of the server.
+func generateResponse(requestID int32, responseType int, payload string) networking.Output {
+	out := networking.NewOutput()
+
+	out.WriteLittleEndianInt32(uint32(requestID))
+
+	out.WriteLittleEndianInt32(uint32(responseType))
+
+	out.WriteNullTerminatedString(payload)
+
+	out.WriteSingleByte(0)
+
+	return out
+}
+
+// Server is an RCON server, which behaves like the vanilla one : responses are fragmented into packets of MaximumResponsePayloadLength bytes,
+// failed authentications and unauthenticated commands are answered with a request ID of -1, and other requests with "Unknown request <type>".
+// Each request must be received in a single read : the connection is closed when a read holds less or more than one packet, so clients must wait for a response before sending the next request.
+type Server struct {
+	mutex    sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+
+	// options
+
+	// Addr is the TCP address listened by ListenAndServe. Defaults to DefaultServerAddr.
+	Addr string
+	// Password is the password of the server. As on vanilla servers, authentication always fails if it is empty.
+	Password string
+	Handler  Handler
+	// ProxyProtocol makes the server require a PROXY protocol header (v1 or v2) at the beginning of each connection, as sent by proxies.
+	// The remote address of connections is then the source address of the header.
+	ProxyProtocol bool
+}
+
+// NewServer returns a well-formed *Server.
+func NewServer(addr string, password string, handler Handler) *Server {
+	return &Server{
+		conns: make(map[net.Conn]struct{}),
+
+		Addr:     addr,
+		Password: password,
+		Handler:  handler,
+	}
+}
+
+// ListenAndServe listens on Addr, and serves connections until the server is closed. It always returns a non-nil error, ErrServerClosed after Close.
+func (server *Server) ListenAndServe() error {
+	addr := server.Addr
+	if addr == "" {
+		addr = DefaultServerAddr
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return server.Serve(l)
+}
+
+// Serve serves the connections accepted by l until the server is closed. l is closed when Serve returns.
+// It always returns a non-nil error, ErrServerClosed after Close.
+func (server *Server) Serve(l net.Listener) error {
+	server.mutex.Lock()
+	if server.closed {
+		server.mutex.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	if server.ProxyProtocol {
+		l = networking.NewProxyProtocolListener(l)
+	}
+	if server.conns == nil {
+		server.conns = make(map[net.Conn]struct{})
+	}
+	server.listener = l
+	server.mutex.Unlock()
+
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			server.mutex.Lock()
+			defer server.mutex.Unlock()
+
+			if server.closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		if !server.track(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		go server.serveConn(conn)
+	}
+}
+
+// Close stops listening, and closes all the connections.
+func (server *Server) Close() error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.closed {
+		return ErrServerClosed
+	}
+	server.closed = true
+
+	var err error
+	if server.listener != nil {
+		err = server.listener.Close()
+	}
+
+	for conn := range server.conns {
+		conn.Close()
+	}
+
+	return err
+}
+
+// track registers conn, to close it with the server. It returns false if the server is closed.
+func (server *Server) track(conn net.Conn) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.closed {
+		return false
+	}
+
+	server.conns[conn] = struct{}{}
+	return true
+}
+
+// serveConn reads and answers the requests of a connection, until it is closed or a malformed packet is received.
+func (server *Server) serveConn(conn net.Conn) {
+	defer func() {
+		server.mutex.Lock()
+		delete(server.conns, conn)
+		server.mutex.Unlock()
+
+		conn.Close()
+	}()
+
+	authenticated := false
+
+	for {
+		p, err := readRequest(conn)
+		if err != nil {
+			return
+		}
+
+		switch int(p.Type) {
+		case LoginRequestType:
+			authenticated = server.Password != "" && p.Payload == server.Password
+			if authenticated {
+				err = writeResponse(conn, p.RequestID, WrongPasswordResponseType, "")
+			} else {
+				err = writeResponse(conn, -1, WrongPasswordResponseType, "")
+			}
+		case CommandRequestType:
+			if authenticated {
+				err = server.writeCommandResponse(conn, p.RequestID, p.Payload)
+			} else {
+				err = writeResponse(conn, -1, WrongPasswordResponseType, "")
+			}
+		default:
+			err = writeResponse(conn, p.RequestID, CommandResponseType, fmt.Sprintf("Unknown request %x", p.Type))
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeCommandResponse executes a command, and writes its response fragmented into packets of MaximumResponsePayloadLength bytes.
+// An empty response is written as a single empty packet.
+func (server *Server) writeCommandResponse(conn net.Conn, requestID int32, command string) error {
+	response := ""
+	if server.Handler != nil {
+		response = server.Handler.ServeRCON(command)
+	}
+
+	for {
+		fragment := response
+		if len(fragment) > MaximumResponsePayloadLength {
+			fragment = fragment[:MaximumResponsePayloadLength]
+		}
+		response = response[len(fragment):]
+
+		err := writeResponse(conn, requestID, CommandResponseType, fragment)
+		if err != nil || response == "" {
+			return err
+		}
+	}
+}
+
+// readRequest reads and parses a request like vanilla servers : in a single read of at most MaximumRequestPacketLength+4 bytes, which must hold exactly one packet.
+// Packets shorter than an empty packet, split over several reads, or read along with other packets are rejected.
+func readRequest(conn net.Conn) (*packet, error) {
+	buf := make([]byte, MaximumRequestPacketLength+4)
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < PacketSizeEmptyPayload {
+		return nil, fmt.Errorf("invalid packet of %d bytes", n)
+	}
+
+	in := networking.NewInput(bytes.NewReader(buf[:n]))
+	length, err := in.ReadLittleEndianInt32()
+	if err != nil {
+		return nil, err
+	}
+
+	if int(length) != n-4 {
+		return nil, fmt.Errorf("invalid packet length %d, %d bytes read", length, n)
+	}
+
+	return parsePacketContent(length, buf[4:n])
+}
+
+// writeResponse writes a response packet in a single write.
+func writeResponse(conn net.Conn, requestID int32, responseType int, payload string) error {
+	response := generateResponse(requestID, responseType, payload)
+
+	responsePacket := transformToPacket(response)
+
+	_, err := conn.Write(responsePacket.Bytes())
+	return err
+}
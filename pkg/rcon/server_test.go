@@ -0,0 +1,193 @@
+package rcon
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+func TestServerVanillaBehavior(t *testing.T) {
+	port := startTestServer(t, "password", echoHandler)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	in := networking.NewInput(conn)
+	long := echoHandler("long")
+
+	inputs := []struct {
+		requestID int32
+		type_     int
+		payload   string
+	}{
+		{1, CommandRequestType, "list"},
+		{2, LoginRequestType, "wrong"},
+		{3, InvalidRequestType, ""},
+		{4, LoginRequestType, "password"},
+		{5, CommandRequestType, ""},
+		{6, CommandRequestType, "long"},
+	}
+	expectedValues := [][]packet{
+		{{RequestID: -1, Type: uint32(WrongPasswordResponseType)}},
+		{{RequestID: -1, Type: uint32(WrongPasswordResponseType)}},
+		{{RequestID: 3, Type: uint32(CommandResponseType), Payload: "Unknown request 4"}},
+		{{RequestID: 4, Type: uint32(WrongPasswordResponseType)}},
+		{{RequestID: 5, Type: uint32(CommandResponseType)}},
+		{
+			{RequestID: 6, Type: uint32(CommandResponseType), Payload: long[:4096]},
+			{RequestID: 6, Type: uint32(CommandResponseType), Payload: long[4096:8192]},
+			{RequestID: 6, Type: uint32(CommandResponseType), Payload: long[8192:12288]},
+			{RequestID: 6, Type: uint32(CommandResponseType), Payload: long[12288:]},
+		},
+	}
+
+	for i := 0; i < len(inputs); i++ {
+		request := generateResponse(inputs[i].requestID, inputs[i].type_, inputs[i].payload)
+		requestPacket := transformToPacket(request)
+
+		_, err := conn.Write(requestPacket.Bytes())
+		if err != nil {
+			t.Fatalf("Error %d: Expected <nil> got %v.", i, err)
+		}
+
+		for j, expected := range expectedValues[i] {
+			p, err := parsePacket(in)
+			if err != nil {
+				t.Fatalf("Error %d.%d: Expected <nil> got %v.", i, j, err)
+			}
+			if p.RequestID != expected.RequestID || p.Type != expected.Type || p.Payload != expected.Payload {
+				t.Errorf("Value %d.%d: Expected %d %d %d bytes got %d %d %d bytes.", i, j, expected.RequestID, expected.Type, len(expected.Payload), p.RequestID, p.Type, len(p.Payload))
+			}
+		}
+	}
+}
+
+func TestServerCoalescedPackets(t *testing.T) {
+	port := startTestServer(t, "password", echoHandler)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Like vanilla, the server closes the connection when a read holds more than one packet.
+	out := networking.NewOutput()
+	loginRequestPacket := transformToPacket(generateResponse(1, LoginRequestType, "password"))
+	commandRequestPacket := transformToPacket(generateResponse(2, CommandRequestType, "list"))
+	out.WriteBytes(loginRequestPacket.Bytes())
+	out.WriteBytes(commandRequestPacket.Bytes())
+
+	_, err = conn.Write(out.Bytes())
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	_, err = parsePacket(networking.NewInput(conn))
+	if err == nil {
+		t.Errorf("Expected error got <nil>.")
+	}
+}
+
+func TestServerClient(t *testing.T) {
+	port := startTestServer(t, "password", strings.ToUpper)
+
+	inputs := []string{"list", "", "say héllo"}
+	expectedValues := []string{"LIST", "", "SAY HÉLLO"}
+
+	for i := 0; i < len(inputs); i++ {
+		res, err := Rcon("127.0.0.1", port, "password", inputs[i])
+		if err != nil {
+			t.Errorf("Error %d: Expected <nil> got %v.", i, err)
+			continue
+		}
+		if res != expectedValues[i] {
+			t.Errorf("Value %d: Expected %s got %s.", i, expectedValues[i], res)
+		}
+	}
+
+	_, err := Rcon("127.0.0.1", port, "wrong", "list")
+	if err != ErrWrongPassword {
+		t.Errorf("Expected %v got %v.", ErrWrongPassword, err)
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	server := NewServer("", "password", HandlerFunc(echoHandler))
+	result := make(chan error, 1)
+	go func() {
+		result <- server.Serve(l)
+	}()
+
+	client := NewClient("127.0.0.1", l.Addr().(*net.TCPAddr).Port)
+	err = client.Connect()
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	ok, err := client.Authenticate("password")
+	if !ok || err != nil {
+		t.Fatalf("Expected true, <nil> got %v, %v.", ok, err)
+	}
+
+	err = server.Close()
+	if err != nil {
+		t.Errorf("Expected <nil> got %v.", err)
+	}
+	if err = <-result; err != ErrServerClosed {
+		t.Errorf("Expected %v got %v.", ErrServerClosed, err)
+	}
+
+	_, err = client.Command("list")
+	if err == nil {
+		t.Errorf("Expected error got <nil>.")
+	}
+}
+
+func TestServerProxyProtocol(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	server := NewServer("", "password", HandlerFunc(strings.ToUpper))
+	server.ProxyProtocol = true
+	go server.Serve(l)
+	defer server.Close()
+
+	// Without header, the server closes the connection.
+	inputs := []networking.ProxyProtocolVersion{networking.ProxyProtocolV1, networking.ProxyProtocolV2, networking.ProxyProtocolNone}
+	expectedValues := []bool{true, true, false}
+
+	for i := 0; i < len(inputs); i++ {
+		client := NewClient("127.0.0.1", l.Addr().(*net.TCPAddr).Port)
+		client.ReadTimeout = time.Second
+		client.ProxyProtocol = inputs[i]
+		client.ProxyProtocolSourceAddr = &net.TCPAddr{IP: net.IPv4(198, 51, 100, 1), Port: 40000}
+
+		err := client.Connect()
+		if err != nil {
+			t.Fatalf("Value %d: Expected <nil> got %v.", i, err)
+		}
+
+		ok, err := client.Authenticate("password")
+		client.Disconnect()
+
+		if (ok && err == nil) != expectedValues[i] {
+			t.Errorf("Value %d: Expected authenticated %v got %v, %v.", i, expectedValues[i], ok, err)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package rcon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+var (
+	ErrSessionClosed error = errors.New("rcon session is closed")
+)
+
+// sessionCommand is a command sent through a session, waiting for its response.
+type sessionCommand struct {
+	command    string
+	requestID  int32
+	sentinelID int32
+	payload    bytes.Buffer
+	// answered is closed when the first packet of the response is received.
+	answered   chan struct{}
+	isAnswered bool
+	// done is closed when the response is complete, or when the session is closed.
+	done   chan struct{}
+	isDone bool
+	err    error
+}
+
+// Session is an authenticated RCON connection, which is safe for concurrent use by multiple goroutines.
+// Each command is sent with a unique request ID, followed by an invalid request (see generateInvalidRequest) whose response marks the end of the command response.
+// Vanilla servers read one packet at a time, and close the connection when a read holds more than one packet : commands are therefore queued, and a single background goroutine
+// writes them one after another, each packet being written only once the server has answered the previous one. Concurrent commands are multiplexed on the connection, but never pipelined.
+type Session struct {
+	conn  *networking.TCPConn
+	queue chan *sessionCommand
+
+	mutex    sync.Mutex
+	nextID   int32
+	inflight *sessionCommand
+	err      error
+	closed   chan struct{}
+
+	// options
+
+	// Timeout is the maximum duration to wait for the response of each command, queueing included. 0 means no timeout.
+	Timeout time.Duration
+}
+
+// Session turns the connection of an authenticated client into a *Session.
+// The session takes ownership of the connection : the client is disconnected, and the connection is closed by Session.Close.
+// Session.Timeout is set to the client ReadTimeout.
+func (client *RCONClient) Session() (*Session, error) {
+	if client.conn == nil {
+		return nil, networking.ErrConnectionNotEstablished
+	}
+	if !client.authenticated {
+		return nil, ErrNotAuthenticated
+	}
+
+	session := newSession(client.conn)
+	session.Timeout = client.ReadTimeout
+
+	client.conn = nil
+	client.authenticated = false
+
+	return session, nil
+}
+
+// newSession returns a well-formed *Session using conn, and starts its background reader and writer.
+func newSession(conn *networking.TCPConn) *Session {
+	session := &Session{
+		conn:   conn,
+		queue:  make(chan *sessionCommand),
+		nextID: 1,
+		closed: make(chan struct{}),
+	}
+
+	go session.readLoop()
+	go session.writeLoop()
+
+	return session
+}
+
+// Command sends a command through the session, and waits for its response.
+// Command length cannot be over MaximumRequestPayloadLength. This is a limitation of the source RCON protocol.
+func (session *Session) Command(command string) (string, error) {
+	return session.CommandContext(context.Background(), command)
+}
+
+// CommandContext is the same as Command, but waiting for the response is aborted as soon as ctx is done.
+// The command may still be executed by the server, its response is then discarded.
+func (session *Session) CommandContext(ctx context.Context, command string) (string, error) {
+	if len(command) > MaximumRequestPayloadLength {
+		return "", ErrCommandTooLong
+	}
+
+	if session.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, session.Timeout)
+		defer cancel()
+	}
+
+	sc, err := session.send(ctx, command)
+	if err != nil {
+		return "", err
+	}
+
+	select {
+	case <-sc.done:
+		if sc.err != nil {
+			return "", sc.err
+		}
+		return sc.payload.String(), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Commands sends commands one after another through the session, and waits for all their responses.
+// Responses are returned in the same order as commands. If an error occurs, responses received so far are returned along with the error, and the following commands are not sent.
+func (session *Session) Commands(commands []string) ([]string, error) {
+	return session.CommandsContext(context.Background(), commands)
+}
+
+// CommandsContext is the same as Commands, but waiting for the responses is aborted as soon as ctx is done.
+func (session *Session) CommandsContext(ctx context.Context, commands []string) ([]string, error) {
+	for _, command := range commands {
+		if len(command) > MaximumRequestPayloadLength {
+			return nil, ErrCommandTooLong
+		}
+	}
+
+	responses := make([]string, 0, len(commands))
+	for _, command := range commands {
+		response, err := session.CommandContext(ctx, command)
+		if err != nil {
+			return responses, err
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// Close closes the session and its connection. Commands waiting for their response fail with ErrSessionClosed.
+func (session *Session) Close() error {
+	session.mutex.Lock()
+	if session.err != nil {
+		session.mutex.Unlock()
+		return ErrSessionClosed
+	}
+	session.err = ErrSessionClosed
+	session.mutex.Unlock()
+
+	err := session.conn.Close()
+	<-session.closed
+	return err
+}
+
+// Done returns a channel which is closed when the session is closed, either by Close or because of a connection error.
+func (session *Session) Done() <-chan struct{} {
+	return session.closed
+}
+
+// Err returns the error which closed the session, or nil if the session is still open.
+func (session *Session) Err() error {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	select {
+	case <-session.closed:
+		return session.err
+	default:
+		return nil
+	}
+}
+
+// send queues a command, and returns as soon as the writer has taken it.
+func (session *Session) send(ctx context.Context, command string) (*sessionCommand, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	session.mutex.Lock()
+	if session.err != nil {
+		session.mutex.Unlock()
+		return nil, session.err
+	}
+	sc := &sessionCommand{
+		command:    command,
+		requestID:  session.allocateID(),
+		sentinelID: session.allocateID(),
+		answered:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	session.mutex.Unlock()
+
+	select {
+	case session.queue <- sc:
+		return sc, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-session.closed:
+		return nil, session.Err()
+	}
+}
+
+// writeLoop writes the queued commands one at a time, until the session is closed.
+// The command request is written first, then the sentinel invalid request once the server has started answering the command, and the next command once the sentinel has been answered.
+// Writes aren't interrupted by the context of the commands, as it would break the connection shared with other commands : even a cancelled command is waited for.
+func (session *Session) writeLoop() {
+	for {
+		var sc *sessionCommand
+		select {
+		case sc = <-session.queue:
+		case <-session.closed:
+			return
+		}
+
+		session.mutex.Lock()
+		if session.err != nil {
+			session.complete(sc, session.err)
+			session.mutex.Unlock()
+			return
+		}
+		session.inflight = sc
+		session.mutex.Unlock()
+
+		err := session.write(transformToPacket(generateCommandRequest(uint32(sc.requestID), sc.command)))
+		if err == nil {
+			err = session.await(sc.answered)
+		}
+		if err == nil {
+			err = session.write(transformToPacket(generateInvalidRequest(uint32(sc.sentinelID))))
+		}
+		if err == nil {
+			err = session.await(sc.done)
+		}
+
+		if err != nil {
+			// The request may have been partially written, so the connection can't be used anymore.
+			session.fail(err)
+			return
+		}
+	}
+}
+
+// write writes out to the connection, within the session timeout.
+func (session *Session) write(out networking.Output) error {
+	var err error
+	if session.Timeout > 0 {
+		err = session.conn.SetWriteDeadline(session.Timeout)
+	} else {
+		err = session.conn.ClearWriteDeadline()
+	}
+	if err != nil {
+		return err
+	}
+
+	return session.conn.WriteContext(context.Background(), out)
+}
+
+// await waits until ch is closed. It returns an error if the session is closed first.
+func (session *Session) await(ch chan struct{}) error {
+	select {
+	case <-ch:
+		return nil
+	case <-session.closed:
+		return ErrSessionClosed
+	}
+}
+
+// allocateID returns the next request ID. IDs are sequential, positive, and never -1 (which is the ID of authentication failures).
+// session.mutex must be held.
+func (session *Session) allocateID() int32 {
+	id := session.nextID
+	if session.nextID == math.MaxInt32 {
+		session.nextID = 1
+	} else {
+		session.nextID++
+	}
+	return id
+}
+
+// answer marks a command as answered. session.mutex must be held.
+func (session *Session) answer(sc *sessionCommand) {
+	if !sc.isAnswered {
+		sc.isAnswered = true
+		close(sc.answered)
+	}
+}
+
+// complete ends a command with err (nil if its response is complete). session.mutex must be held.
+func (session *Session) complete(sc *sessionCommand, err error) {
+	session.answer(sc)
+	if !sc.isDone {
+		sc.isDone = true
+		sc.err = err
+		close(sc.done)
+	}
+}
+
+// fail closes the session because of err, and makes the pending command fail with it.
+func (session *Session) fail(err error) {
+	session.mutex.Lock()
+	if session.err == nil {
+		session.err = err
+	}
+	session.mutex.Unlock()
+
+	session.conn.Close()
+}
+
+// readLoop reads all responses from the connection, and dispatches them to the command in flight, until the connection is closed.
+// Responses are awaited without read deadline, as the session may stay idle : timeouts are handled per command.
+func (session *Session) readLoop() {
+	in := session.conn.Input()
+
+	err := session.conn.ClearReadDeadline()
+	if err != nil {
+		session.fail(err)
+	}
+
+	for {
+		p, err := parsePacket(in)
+		if err != nil {
+			session.fail(err)
+			break
+		}
+
+		if p.RequestID == -1 {
+			// The server doesn't consider the connection authenticated anymore.
+			session.fail(ErrNotAuthenticated)
+			break
+		}
+
+		session.mutex.Lock()
+		if sc := session.inflight; sc != nil && p.RequestID == sc.requestID {
+			sc.payload.WriteString(p.Payload)
+			session.answer(sc)
+		} else if sc != nil && p.RequestID == sc.sentinelID {
+			session.inflight = nil
+			session.complete(sc, nil)
+		}
+		session.mutex.Unlock()
+	}
+
+	session.mutex.Lock()
+	if session.inflight != nil {
+		session.complete(session.inflight, session.err)
+		session.inflight = nil
+	}
+	session.mutex.Unlock()
+
+	close(session.closed)
+}
@@ -0,0 +1,332 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xrjr/mcutils/pkg/networking"
+)
+
+// testServerOptions customizes the behavior of the test server, to mimic servers other than vanilla.
+type testServerOptions struct {
+	// FragmentLength is the maximum payload length of response packets. Defaults to MaximumResponsePayloadLength.
+	FragmentLength int
+	// UnknownRequest is the response to invalid requests. Defaults to "Unknown request <type>".
+	UnknownRequest string
+	// Source makes the server behave like a source server : empty response packets are mirrored, followed by a 0x00000001 packet, and invalid requests are ignored.
+	Source bool
+	// CloseOn makes the server close the connection, without answering, when it returns true for the received command.
+	CloseOn func(command string) bool
+	// ForgetOn makes the server answer the received command as if the connection wasn't authenticated (request ID -1), when it returns true for it.
+	ForgetOn func(command string) bool
+	// StallInPacket makes the server stop in the middle of the second packet of fragmented responses, without closing the connection.
+	StallInPacket bool
+}
+
+// startTestServer starts a vanilla-like rcon server (see Server), executing commands with handler, and returns its port.
+func startTestServer(t *testing.T, password string, handler func(command string) string) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	server := NewServer("", password, HandlerFunc(handler))
+	go server.Serve(l)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startTestServerWithOptions starts a minimal rcon server, behaving like vanilla unless options says otherwise, and returns its port.
+func startTestServerWithOptions(t *testing.T, password string, handler func(command string) string, options testServerOptions) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	t.Cleanup(func() {
+		l.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				in := networking.NewInput(conn)
+				authenticated := false
+
+				for {
+					var p *packet
+					var err error
+					if options.Source {
+						p, err = parsePacket(in)
+					} else {
+						p, err = readRequest(conn)
+					}
+					if err != nil {
+						return
+					}
+
+					switch {
+					case int(p.Type) == LoginRequestType:
+						authenticated = p.Payload == password
+						requestID := p.RequestID
+						if !authenticated {
+							requestID = -1
+						}
+						err = writeResponse(conn, requestID, WrongPasswordResponseType, "")
+					case !authenticated:
+						return
+					case int(p.Type) == CommandRequestType && options.CloseOn != nil && options.CloseOn(p.Payload):
+						return
+					case int(p.Type) == CommandRequestType && options.ForgetOn != nil && options.ForgetOn(p.Payload):
+						authenticated = false
+						err = writeResponse(conn, -1, CommandResponseType, "")
+					case int(p.Type) == CommandRequestType:
+						fragmentLength := options.FragmentLength
+						if fragmentLength == 0 {
+							fragmentLength = MaximumResponsePayloadLength
+						}
+
+						response := handler(p.Payload)
+						for len(response) > fragmentLength {
+							err = writeResponse(conn, p.RequestID, CommandResponseType, response[:fragmentLength])
+							if err != nil {
+								return
+							}
+							response = response[fragmentLength:]
+
+							if options.StallInPacket {
+								responsePacket := transformToPacket(generateResponse(p.RequestID, CommandResponseType, response))
+								conn.Write(responsePacket.Bytes()[:6])
+								io.Copy(io.Discard, conn)
+								return
+							}
+						}
+						err = writeResponse(conn, p.RequestID, CommandResponseType, response)
+					case options.Source && int(p.Type) == CommandResponseType:
+						err = writeResponse(conn, p.RequestID, CommandResponseType, "")
+						if err == nil {
+							err = writeResponse(conn, p.RequestID, CommandResponseType, "\x00\x00\x00\x01\x00\x00\x00")
+						}
+					case options.Source:
+					case options.UnknownRequest != "":
+						err = writeResponse(conn, p.RequestID, CommandResponseType, options.UnknownRequest)
+					default:
+						err = writeResponse(conn, p.RequestID, CommandResponseType, fmt.Sprintf("Unknown request %x", p.Type))
+					}
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// echoHandler answers each command with the command itself, or a long response for the "long" command.
+func echoHandler(command string) string {
+	if command == "long" {
+		return strings.Repeat("a", 3*MaximumResponsePayloadLength+10)
+	}
+	if command == "slow" {
+		time.Sleep(200 * time.Millisecond)
+	}
+	return command
+}
+
+func TestSessionConcurrentCommands(t *testing.T) {
+	port := startTestServer(t, "password", echoHandler)
+
+	session, err := OpenSession("127.0.0.1", port, "password")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer session.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			command := fmt.Sprintf("say %d", i)
+			if i%5 == 0 {
+				command = "long"
+			}
+
+			res, err := session.Command(command)
+			if err != nil {
+				t.Errorf("Error %d: Expected <nil> got %v.", i, err)
+				return
+			}
+			if res != echoHandler(command) {
+				t.Errorf("Value %d: Expected %d bytes got %d bytes.", i, len(echoHandler(command)), len(res))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSessionCommands(t *testing.T) {
+	port := startTestServer(t, "password", echoHandler)
+
+	session, err := OpenSession("127.0.0.1", port, "password")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer session.Close()
+
+	commands := []string{"list", "long", "", "time query daytime"}
+
+	res, err := session.Commands(commands)
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	if len(res) != len(commands) {
+		t.Fatalf("Expected %d responses got %d.", len(commands), len(res))
+	}
+	for i := range commands {
+		if res[i] != echoHandler(commands[i]) {
+			t.Errorf("Value %d: Expected %d bytes got %d bytes.", i, len(echoHandler(commands[i])), len(res[i]))
+		}
+	}
+}
+
+func TestSessionOnePacketPerRead(t *testing.T) {
+	// Like vanilla, the server closes the connection when a read holds more than one packet.
+	port := startTestServerWithOptions(t, "password", echoHandler, testServerOptions{})
+
+	session, err := OpenSession("127.0.0.1", port, "password")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer session.Close()
+
+	commands := []string{"list", "long", "", "time query daytime"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			res, err := session.Commands(commands)
+			if err != nil {
+				t.Errorf("Error %d: Expected <nil> got %v.", i, err)
+				return
+			}
+			for j := range commands {
+				if res[j] != echoHandler(commands[j]) {
+					t.Errorf("Value %d: Expected %d bytes got %d bytes.", i, len(echoHandler(commands[j])), len(res[j]))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSessionTimeout(t *testing.T) {
+	port := startTestServer(t, "password", echoHandler)
+
+	session, err := OpenSession("127.0.0.1", port, "password")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer session.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = session.CommandContext(ctx, "slow")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v got %v.", context.DeadlineExceeded, err)
+	}
+
+	// The late response of the slow command must not be mistaken for the response of the next one.
+	res, err := session.Command("next")
+	if err != nil || res != "next" {
+		t.Errorf("Expected next, <nil> got %v, %v.", res, err)
+	}
+}
+
+func TestSessionCancelledCommands(t *testing.T) {
+	port := startTestServer(t, "password", echoHandler)
+
+	session, err := OpenSession("127.0.0.1", port, "password")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+	defer session.Close()
+
+	// Commands cancelled around their write must not break the connection shared with the other commands.
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i)*time.Microsecond)
+			defer cancel()
+			session.CommandContext(ctx, "cancelled")
+		}(i)
+	}
+	wg.Wait()
+
+	res, err := session.Command("next")
+	if err != nil || res != "next" {
+		t.Errorf("Expected next, <nil> got %v, %v.", res, err)
+	}
+}
+
+func TestSessionClose(t *testing.T) {
+	port := startTestServer(t, "password", echoHandler)
+
+	_, err := OpenSession("127.0.0.1", port, "wrong")
+	if err != ErrWrongPassword {
+		t.Errorf("Expected %v got %v.", ErrWrongPassword, err)
+	}
+
+	session, err := OpenSession("127.0.0.1", port, "password")
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := session.Command("slow")
+		result <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	err = session.Close()
+	if err != nil {
+		t.Errorf("Expected <nil> got %v.", err)
+	}
+	if err = <-result; err != ErrSessionClosed {
+		t.Errorf("Expected %v got %v.", ErrSessionClosed, err)
+	}
+
+	_, err = session.Command("list")
+	if err != ErrSessionClosed {
+		t.Errorf("Expected %v got %v.", ErrSessionClosed, err)
+	}
+}
@@ -0,0 +1,160 @@
+// status package provides ServerStatus, a common representation of the informations returned by every protocol (ping, legacy ping, query and bedrock ping).
+package status
+
+import (
+	"strconv"
+
+	"github.com/xrjr/mcutils/pkg/bedrock"
+	"github.com/xrjr/mcutils/pkg/chat"
+	"github.com/xrjr/mcutils/pkg/ping"
+	"github.com/xrjr/mcutils/pkg/query"
+)
+
+// Edition is the edition of the game a server is running.
+type Edition string
+
+const (
+	EditionJava    Edition = "java"
+	EditionBedrock Edition = "bedrock"
+)
+
+// Source is the protocol a ServerStatus was built from.
+type Source string
+
+const (
+	SourcePing        Source = "ping"
+	SourcePingLegacy  Source = "ping-legacy"
+	SourceQueryBasic  Source = "query-basic"
+	SourceQueryFull   Source = "query-full"
+	SourcePingBedrock Source = "ping-bedrock"
+)
+
+// Player is a player connected to the server. ID is only known when the player comes from a ping players sample.
+type Player struct {
+	Name string `json:"name"`
+	ID   string `json:"id,omitempty"`
+}
+
+// ServerStatus is the normalized status of a server, whatever the protocol used to get it.
+// Fields which are not provided by the source protocol are left empty : for example, Protocol is 0 if the protocol version is unknown,
+// and Players only contains the players sample (ping) or the full list of players (full stat query).
+type ServerStatus struct {
+	Edition Edition `json:"edition"`
+	Source  Source  `json:"source"`
+
+	Version  string         `json:"version"`
+	Protocol int            `json:"protocol"`
+	MOTD     chat.Component `json:"motd"`
+
+	OnlinePlayers int      `json:"onlinePlayers"`
+	MaxPlayers    int      `json:"maxPlayers"`
+	Players       []Player `json:"players,omitempty"`
+
+	Map      string `json:"map,omitempty"`
+	GameMode string `json:"gameMode,omitempty"`
+	Favicon  string `json:"favicon,omitempty"`
+}
+
+// FromInfos converts ping infos (see ping.JSON.Infos) into a ServerStatus.
+func FromInfos(infos ping.Infos) ServerStatus {
+	ss := ServerStatus{
+		Edition:       EditionJava,
+		Source:        SourcePing,
+		Version:       infos.Version.Name,
+		Protocol:      infos.Version.Protocol,
+		MOTD:          infos.DescriptionComponent,
+		OnlinePlayers: infos.Players.Online,
+		MaxPlayers:    infos.Players.Max,
+		Favicon:       infos.Favicon,
+	}
+
+	for _, player := range infos.Players.Sample {
+		ss.Players = append(ss.Players, Player{Name: player.Name, ID: player.ID})
+	}
+
+	return ss
+}
+
+// FromStatus converts a ping status into a ServerStatus.
+func FromStatus(s ping.Status) ServerStatus {
+	ss := ServerStatus{
+		Edition:       EditionJava,
+		Source:        SourcePing,
+		Version:       s.Version.Name,
+		Protocol:      s.Version.Protocol,
+		MOTD:          s.Description,
+		OnlinePlayers: s.Players.Online,
+		MaxPlayers:    s.Players.Max,
+		Favicon:       string(s.Favicon),
+	}
+
+	for _, player := range s.Players.Sample {
+		ss.Players = append(ss.Players, Player{Name: player.Name, ID: player.ID})
+	}
+
+	return ss
+}
+
+// FromLegacyPingInfos converts legacy ping infos into a ServerStatus.
+// Protocol is the pre-netty protocol version (see protocol.JavaLegacyVersions), and is 0 for servers older than 1.4.
+func FromLegacyPingInfos(infos ping.LegacyPingInfos) ServerStatus {
+	return ServerStatus{
+		Edition:       EditionJava,
+		Source:        SourcePingLegacy,
+		Version:       infos.MinecraftVersion,
+		Protocol:      infos.ProtocolVersion,
+		MOTD:          infos.MOTDComponent(),
+		OnlinePlayers: infos.OnlinePlayers,
+		MaxPlayers:    infos.MaxPlayers,
+	}
+}
+
+// FromBasicStat converts a basic stat query into a ServerStatus.
+func FromBasicStat(bs query.BasicStat) ServerStatus {
+	return ServerStatus{
+		Edition:       EditionJava,
+		Source:        SourceQueryBasic,
+		MOTD:          bs.MOTDComponent(),
+		OnlinePlayers: bs.NumPlayers,
+		MaxPlayers:    bs.MaxPlayers,
+		Map:           bs.Map,
+		GameMode:      bs.GameType,
+	}
+}
+
+// FromFullStat converts a full stat query into a ServerStatus.
+// Player counts which are missing or malformed in the properties are left to 0.
+func FromFullStat(fs query.FullStat) ServerStatus {
+	ss := ServerStatus{
+		Edition:  EditionJava,
+		Source:   SourceQueryFull,
+		Version:  fs.Properties["version"],
+		MOTD:     chat.FromLegacy(fs.Properties["hostname"]),
+		Map:      fs.Properties["map"],
+		GameMode: fs.Properties["gametype"],
+	}
+
+	ss.OnlinePlayers, _ = strconv.Atoi(fs.Properties["numplayers"])
+	ss.MaxPlayers, _ = strconv.Atoi(fs.Properties["maxplayers"])
+
+	for _, player := range fs.OnlinePlayers {
+		ss.Players = append(ss.Players, Player{Name: player})
+	}
+
+	return ss
+}
+
+// FromUnconnectedPong converts a bedrock unconnected pong into a ServerStatus.
+func FromUnconnectedPong(up bedrock.UnconnectedPong) ServerStatus {
+	return ServerStatus{
+		Edition:       EditionBedrock,
+		Source:        SourcePingBedrock,
+		Version:       up.MinecraftVersion,
+		Protocol:      up.ProtocolVersion,
+		MOTD:          up.MOTDComponent(),
+		OnlinePlayers: up.OnlinePlayers,
+		MaxPlayers:    up.MaxPlayers,
+		Map:           up.LevelName,
+		GameMode:      up.GameMode,
+	}
+}
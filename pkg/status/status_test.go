@@ -0,0 +1,52 @@
+package status
+
+import (
+	"testing"
+
+	"github.com/xrjr/mcutils/pkg/bedrock"
+	"github.com/xrjr/mcutils/pkg/ping"
+	"github.com/xrjr/mcutils/pkg/query"
+)
+
+func TestServerStatus(t *testing.T) {
+	s, err := ping.ParseStatus([]byte(`{"version": {"name": "1.20.4", "protocol": 765}, "players": {"max": 20, "online": 1, "sample": [{"name": "Notch", "id": "069a79f4-44e9-4726-a5be-fca90e38aaf5"}]}, "description": {"text": "§aHello"}}`))
+	if err != nil {
+		t.Fatalf("Expected <nil> got %v.", err)
+	}
+
+	inputs := []ServerStatus{
+		FromStatus(s),
+		FromLegacyPingInfos(ping.LegacyPingInfos{ProtocolVersion: 78, MinecraftVersion: "1.6.4", MOTD: "§aHello", OnlinePlayers: 1, MaxPlayers: 20}),
+		FromBasicStat(query.BasicStat{MOTD: "§aHello", GameType: "SMP", Map: "world", NumPlayers: 1, MaxPlayers: 20}),
+		FromFullStat(query.FullStat{
+			Properties:    map[string]string{"hostname": "§aHello", "gametype": "SMP", "version": "1.20.4", "map": "world", "numplayers": "1", "maxplayers": "20"},
+			OnlinePlayers: []string{"Notch"},
+		}),
+		FromUnconnectedPong(bedrock.UnconnectedPong{MOTD: "§aHello", ProtocolVersion: 671, MinecraftVersion: "1.20.80", OnlinePlayers: 1, MaxPlayers: 20, LevelName: "world", GameMode: "Survival"}),
+	}
+	expectedEditions := []Edition{EditionJava, EditionJava, EditionJava, EditionJava, EditionBedrock}
+	expectedSources := []Source{SourcePing, SourcePingLegacy, SourceQueryBasic, SourceQueryFull, SourcePingBedrock}
+	expectedVersions := []string{"1.20.4", "1.6.4", "", "1.20.4", "1.20.80"}
+	expectedProtocols := []int{765, 78, 0, 0, 671}
+	expectedPlayers := []int{1, 0, 0, 1, 0}
+
+	for i := 0; i < len(inputs); i++ {
+		res := inputs[i]
+
+		if res.Edition != expectedEditions[i] || res.Source != expectedSources[i] {
+			t.Errorf("Value %d: Expected %v, %v got %v, %v.", i, expectedEditions[i], expectedSources[i], res.Edition, res.Source)
+		}
+		if res.Version != expectedVersions[i] || res.Protocol != expectedProtocols[i] {
+			t.Errorf("Value %d: Expected %v, %v got %v, %v.", i, expectedVersions[i], expectedProtocols[i], res.Version, res.Protocol)
+		}
+		if res.MOTD.PlainText() != "Hello" || res.MOTD.Legacy() != "§aHello" {
+			t.Errorf("Value %d: Expected %q got %q.", i, "§aHello", res.MOTD.Legacy())
+		}
+		if res.OnlinePlayers != 1 || res.MaxPlayers != 20 {
+			t.Errorf("Value %d: Expected %d/%d got %d/%d.", i, 1, 20, res.OnlinePlayers, res.MaxPlayers)
+		}
+		if len(res.Players) != expectedPlayers[i] || (len(res.Players) > 0 && res.Players[0].Name != "Notch") {
+			t.Errorf("Value %d: Expected %d players got %v.", i, expectedPlayers[i], res.Players)
+		}
+	}
+}